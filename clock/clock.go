@@ -0,0 +1,256 @@
+package clock
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Epoch is the in-game time at which a new simulation starts.
+var Epoch = time.Date(2031, time.March, 14, 9, 0, 0, 0, time.UTC)
+
+// MaxSpeed is the fastest a clock may run, an hour for every real second.
+const MaxSpeed = 3600
+
+// Clock tracks simulated time. It starts at a fixed point in game time and
+// advances alongside the wall clock, scaled by its speed, so timestamps
+// inside the world never depend on when the game happens to be played. It
+// can be paused, and a fake clock only moves when told to, which makes
+// anything scheduled on it deterministic.
+//
+// Callbacks scheduled with AfterFunc and At run on the clock's own goroutine
+// for a real clock, or on the caller of Advance for a fake one.
+type Clock struct {
+	mu sync.Mutex
+
+	// base is the simulated time when the wall clock read anchor
+	base   time.Time
+	anchor time.Time
+	speed  float64
+	paused bool
+	fake   bool
+
+	timers timerHeap
+	// seq numbers timers in the order they were set
+	seq uint64
+	// wake interrupts the scheduler when the next timer may have changed
+	wake chan struct{}
+}
+
+func New(start time.Time) *Clock {
+	c := &Clock{
+		base:   start,
+		anchor: time.Now(),
+		speed:  1,
+		wake:   make(chan struct{}, 1),
+	}
+	go c.run()
+	return c
+}
+
+// NewFake returns a clock that stands still at start until Advance is called.
+func NewFake(start time.Time) *Clock {
+	return &Clock{
+		base:  start,
+		speed: 1,
+		fake:  true,
+	}
+}
+
+// Fake reports whether the clock only moves when Advance is called.
+func (c *Clock) Fake() bool {
+	return c.fake
+}
+
+// Now returns the current simulated time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now()
+}
+
+func (c *Clock) now() time.Time {
+	if c.fake || c.paused {
+		return c.base
+	}
+	return c.base.Add(time.Duration(float64(time.Since(c.anchor)) * c.speed))
+}
+
+// reanchor folds the time elapsed so far into base, so the rate can change
+// without time jumping.
+func (c *Clock) reanchor() {
+	c.base = c.now()
+	c.anchor = time.Now()
+}
+
+func (c *Clock) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reanchor()
+	c.paused = true
+	c.poke()
+}
+
+func (c *Clock) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reanchor()
+	c.paused = false
+	c.poke()
+}
+
+func (c *Clock) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// SetSpeed sets how many simulated seconds pass for each real one. It must
+// be positive and no more than MaxSpeed.
+func (c *Clock) SetSpeed(speed float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reanchor()
+	c.speed = speed
+	c.poke()
+}
+
+func (c *Clock) Speed() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.speed
+}
+
+// Advance moves a fake clock forward by d, running every callback that
+// falls due along the way in order. Paused clocks don't move.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	if !c.fake {
+		c.mu.Unlock()
+		panic("clock: Advance called on a real clock")
+	}
+	if c.paused {
+		c.mu.Unlock()
+		return
+	}
+
+	end := c.base.Add(d)
+	for len(c.timers) > 0 && !c.timers[0].when.After(end) {
+		t := heap.Pop(&c.timers).(*Timer)
+		c.base = t.when
+		c.mu.Unlock()
+		t.fn()
+		c.mu.Lock()
+	}
+	c.base = end
+	c.mu.Unlock()
+}
+
+// Timer is a callback waiting for a simulated time.
+type Timer struct {
+	when  time.Time
+	fn    func()
+	seq   uint64
+	index int
+}
+
+// AfterFunc runs fn once d of simulated time has passed.
+func (c *Clock) AfterFunc(d time.Duration, fn func()) *Timer {
+	return c.At(c.Now().Add(d), fn)
+}
+
+// At runs fn once the simulated time reaches when.
+func (c *Clock) At(when time.Time, fn func()) *Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	t := &Timer{when: when, fn: fn, seq: c.seq}
+	heap.Push(&c.timers, t)
+	c.poke()
+	return t
+}
+
+// Stop cancels the timer, reporting whether it had still to fire.
+func (c *Clock) Stop(t *Timer) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t.index < 0 || t.index >= len(c.timers) || c.timers[t.index] != t {
+		return false
+	}
+	heap.Remove(&c.timers, t.index)
+	c.poke()
+	return true
+}
+
+// poke wakes the scheduler of a real clock so it can look at the timers
+// again. The caller must hold mu.
+func (c *Clock) poke() {
+	if c.fake {
+		return
+	}
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run fires the timers of a real clock as they fall due.
+func (c *Clock) run() {
+	for {
+		c.mu.Lock()
+		if len(c.timers) > 0 && !c.paused && !c.timers[0].when.After(c.now()) {
+			t := heap.Pop(&c.timers).(*Timer)
+			c.mu.Unlock()
+			t.fn()
+			continue
+		}
+
+		// Sleep until the next timer is due in wall time, or until
+		// something changes
+		var wait <-chan time.Time
+		if len(c.timers) > 0 && !c.paused {
+			wait = time.After(time.Duration(float64(c.timers[0].when.Sub(c.now())) / c.speed))
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-c.wake:
+		}
+	}
+}
+
+// timerHeap orders timers by when they are due, earliest first.
+type timerHeap []*Timer
+
+func (h timerHeap) Len() int { return len(h) }
+
+func (h timerHeap) Less(i, j int) bool {
+	// Timers due at the same moment fire in the order they were set
+	if h[i].when.Equal(h[j].when) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].when.Before(h[j].when)
+}
+
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x interface{}) {
+	t := x.(*Timer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *timerHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	t.index = -1
+	*h = old[:len(old)-1]
+	return t
+}
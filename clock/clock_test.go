@@ -0,0 +1,110 @@
+package clock
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFakeStandsStill(t *testing.T) {
+	c := NewFake(Epoch)
+	if got := c.Now(); !got.Equal(Epoch) {
+		t.Fatalf("Now() = %v, want %v", got, Epoch)
+	}
+
+	c.Advance(90 * time.Second)
+	if got, want := c.Now(), Epoch.Add(90*time.Second); !got.Equal(want) {
+		t.Fatalf("Now() after Advance = %v, want %v", got, want)
+	}
+}
+
+func TestAdvanceRunsTimersInOrder(t *testing.T) {
+	c := NewFake(Epoch)
+	fired := []string{}
+	record := func(name string) func() {
+		return func() { fired = append(fired, name) }
+	}
+
+	c.AfterFunc(3*time.Second, record("c"))
+	c.AfterFunc(1*time.Second, record("a"))
+	c.At(Epoch.Add(2*time.Second), record("b1"))
+	c.At(Epoch.Add(2*time.Second), record("b2"))
+	c.AfterFunc(time.Minute, record("late"))
+
+	c.Advance(3 * time.Second)
+	if want := []string{"a", "b1", "b2", "c"}; !reflect.DeepEqual(fired, want) {
+		t.Fatalf("fired %v, want %v", fired, want)
+	}
+}
+
+func TestAdvanceSetsNowForCallbacks(t *testing.T) {
+	c := NewFake(Epoch)
+	var at time.Time
+	c.AfterFunc(5*time.Second, func() { at = c.Now() })
+
+	c.Advance(time.Minute)
+	if want := Epoch.Add(5 * time.Second); !at.Equal(want) {
+		t.Fatalf("callback saw %v, want %v", at, want)
+	}
+}
+
+func TestAdvanceRunsTimersSetByCallbacks(t *testing.T) {
+	c := NewFake(Epoch)
+	ticks := 0
+	var tick func()
+	tick = func() {
+		ticks++
+		c.AfterFunc(time.Second, tick)
+	}
+	c.AfterFunc(time.Second, tick)
+
+	c.Advance(10 * time.Second)
+	if ticks != 10 {
+		t.Fatalf("ticked %d times, want 10", ticks)
+	}
+}
+
+func TestStop(t *testing.T) {
+	c := NewFake(Epoch)
+	fired := false
+	timer := c.AfterFunc(time.Second, func() { fired = true })
+
+	if !c.Stop(timer) {
+		t.Fatal("Stop() = false for a pending timer")
+	}
+	if c.Stop(timer) {
+		t.Fatal("Stop() = true for a stopped timer")
+	}
+
+	c.Advance(time.Minute)
+	if fired {
+		t.Fatal("stopped timer fired")
+	}
+}
+
+func TestPausedFakeDoesNotAdvance(t *testing.T) {
+	c := NewFake(Epoch)
+	fired := false
+	c.AfterFunc(time.Second, func() { fired = true })
+
+	c.Pause()
+	c.Advance(time.Minute)
+	if fired || !c.Now().Equal(Epoch) {
+		t.Fatal("paused clock moved")
+	}
+
+	c.Resume()
+	c.Advance(time.Minute)
+	if !fired {
+		t.Fatal("timer didn't fire after resuming")
+	}
+}
+
+func TestAdvancePanicsOnRealClock(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Advance on a real clock didn't panic")
+		}
+	}()
+	New(Epoch).Advance(time.Second)
+}
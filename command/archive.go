@@ -0,0 +1,138 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+// archiveListing lists every file in an archive by its path inside it
+func archiveListing(dir *fs.Directory, prefix string) []string {
+	names := []string{}
+	for _, file := range dir.Files {
+		names = append(names, file.Name())
+	}
+	sort.Strings(names)
+
+	lines := []string{}
+	for _, name := range names {
+		file := dir.Files[strings.ToLower(name)]
+		if sub, ok := file.(*fs.Directory); ok {
+			lines = append(lines, prefix+name+"/")
+			lines = append(lines, archiveListing(sub, prefix+name+"/")...)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s (%d)", prefix+name, file.Size()))
+	}
+	return lines
+}
+
+var ZipCommand = &Command{
+	ShortHelp: "Bundle files and directories into an archive",
+	LongHelp: `Bundle copies of files and directories, with everything beneath them, into a new archive file.
+Usage: zip [archive] [path...]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) < 2 {
+			return nil, fmt.Errorf("must supply an archive and at least one path")
+		}
+
+		dir, name, err := state.findNewFilePath(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		now := state.Clock.Now()
+		root := fs.NewDirectory(nil, "", now)
+		for _, path := range args[1:] {
+			file := state.FindFile(path)
+			if file == nil {
+				return nil, fmt.Errorf("%s: file not found", path)
+			}
+			if file == state.CurrentHost.RootDir {
+				return nil, fmt.Errorf("cannot archive /")
+			}
+
+			copied, err := fs.Copy(file, file.Name(), now)
+			if err != nil {
+				return nil, err
+			}
+			if sub, ok := copied.(*fs.Directory); ok {
+				sub.Parent = root
+			}
+			root.Files[strings.ToLower(copied.Name())] = copied
+		}
+
+		if err := state.addFile(dir, fs.NewArchive(name, root, now)); err != nil {
+			return nil, err
+		}
+		return []byte(fmt.Sprintf("created %s with %d entries", args[0], len(archiveListing(root, "")))), nil
+	},
+}
+
+var UnzipCommand = &Command{
+	ShortHelp: "Extract the files in an archive",
+	LongHelp: `Extract the files in an archive into the current directory, or another one if given. Nothing
+is extracted if any of the archive's files are already there. With -l the archive's files are
+listed instead.
+Usage: unzip [-l] [archive] [directory]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		flags, args, err := parseFlags(args, "l")
+		if err != nil {
+			return nil, err
+		}
+		if len(args) < 1 || len(args) > 2 {
+			return nil, fmt.Errorf("must supply an archive and optionally a directory")
+		}
+
+		file := state.FindFile(args[0])
+		if file == nil {
+			return nil, fmt.Errorf("%s: file not found", args[0])
+		}
+		archive, ok := file.(*fs.Archive)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an archive", args[0])
+		}
+
+		if flags['l'] {
+			return []byte(strings.Join(archiveListing(archive.Root, ""), "\n")), nil
+		}
+
+		dir := state.CurrentDir
+		if len(args) == 2 {
+			if dir, ok = state.FindFile(args[1]).(*fs.Directory); !ok {
+				return nil, fmt.Errorf("%s is not a directory", args[1])
+			}
+		}
+
+		for key, f := range archive.Root.Files {
+			if _, ok := dir.Files[key]; ok {
+				return nil, fmt.Errorf("%s already exists", f.Name())
+			}
+		}
+		if err := state.reserve(dir, archive.Root.Size()); err != nil {
+			return nil, err
+		}
+
+		now := state.Clock.Now()
+		buf := &bytes.Buffer{}
+		for _, f := range archive.Root.Files {
+			copied, err := fs.Copy(f, f.Name(), now)
+			if err != nil {
+				return nil, err
+			}
+			if err := state.addFile(dir, copied); err != nil {
+				return nil, err
+			}
+		}
+		for _, line := range archiveListing(archive.Root, "") {
+			buf.WriteString(fmt.Sprintf("  extracting: %s\n", line))
+		}
+
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+	},
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"strings"
+	"time"
+)
+
+// auditLogPath is where hosts record who connected and what they ran
+const auditLogPath = "/var/log/audit.log"
+
+// auditTimeFormat is the timestamp at the start of each audit log entry
+const auditTimeFormat = "Jan 02 15:04:05"
+
+// address is how other hosts see this one in their logs
+func (h *Host) address() string {
+	if ip := h.PrimaryIP(); ip != nil {
+		return ip.String()
+	}
+	return h.Hostname
+}
+
+// audit appends an entry to the host's audit log, creating the log if it
+// has been removed. Entries record the time, the host the user came from,
+// the user and what they did.
+func (h *Host) audit(now time.Time, source *Host, user, entry string) {
+	line := strings.Join([]string{now.Format(auditTimeFormat), source.address(), user, entry}, " ") + "\n"
+	h.appendFile(auditLogPath, line, now)
+}
+
+// sourceHost is the host the player connected to the current host from
+func (s *State) sourceHost() *Host {
+	if len(s.Chain) < 2 {
+		return s.LocalHost
+	}
+	return s.Chain[len(s.Chain)-2]
+}
+
+// auditCommand records a command the player is about to run on a remote
+// host. Commands are logged before they run, so removing the log takes the
+// entry for the removal with it. Leaving isn't recorded.
+func (s *State) auditCommand(cmdName string, args []string) {
+	if s.CurrentHost == s.LocalHost || cmdName == "exit" {
+		return
+	}
+
+	entry := strings.Join(append([]string{cmdName}, args...), " ")
+	s.CurrentHost.audit(s.Clock.Now(), s.sourceHost(), s.CurrentUser.Name, entry)
+}
@@ -3,6 +3,7 @@ package command
 import (
 	"bytes"
 	"fmt"
+	"path"
 	"sort"
 	"strings"
 
@@ -25,39 +26,80 @@ type Command struct {
 
 func standardCommands() map[string]*Command {
 	return map[string]*Command{
-		"help":    HelpCommand,
-		"ls":      LSCommand,
-		"mkdir":   MKDIRCommand,
-		"cd":      CDCommand,
-		"exit":    ExitCommand,
-		"rmdir":   RMDIRCommand,
-		"append":  AppendCommand,
-		"cat":     CatCommand,
-		"replace": ReplaceCommand,
-		"scan":    ScanCommand,
-		"connect": ConnectCommand,
+		"help":       HelpCommand,
+		"ls":         LSCommand,
+		"mkdir":      MKDIRCommand,
+		"cd":         CDCommand,
+		"exit":       ExitCommand,
+		"rmdir":      RMDIRCommand,
+		"rm":         RMCommand,
+		"append":     AppendCommand,
+		"cat":        CatCommand,
+		"replace":    ReplaceCommand,
+		"scan":       ScanCommand,
+		"connect":    ConnectCommand,
+		"less":       LessCommand,
+		"more":       LessCommand,
+		"pager":      PagerCommand,
+		"alias":      AliasCommand,
+		"ln":         LNCommand,
+		"readlink":   ReadlinkCommand,
+		"firewall":   FirewallCommand,
+		"scp":        SCPCommand,
+		"ping":       PingCommand,
+		"traceroute": TracerouteCommand,
+		"ifconfig":   IfconfigCommand,
+		"ip":         IPCommand,
+		"show":       ShowCommand,
+		"nslookup":   NSLookupCommand,
+		"dig":        DigCommand,
+		"map":        MapCommand,
+		"mail":       MailCommand,
+		"date":       DateCommand,
+		"clock":      ClockCommand,
+		"crontab":    CrontabCommand,
+		"ps":         PSCommand,
+		"top":        TopCommand,
+		"kill":       KillCommand,
+		"jobs":       JobsCommand,
+		"fg":         FGCommand,
+		"install":    InstallCommand,
+		"encrypt":    EncryptCommand,
+		"decrypt":    DecryptCommand,
+		"zip":        ZipCommand,
+		"unzip":      UnzipCommand,
+		"hexdump":    HexdumpCommand,
+		"xxd":        XXDCommand,
+		"strings":    StringsCommand,
+		"df":         DFCommand,
+		"du":         DUCommand,
+		"mount":      MountCommand,
+		"umount":     UmountCommand,
+		"missions":   MissionsCommand,
 	}
 }
 
 var HelpCommand = &Command{
 	ShortHelp: "Display help for installed commands",
-	LongHelp: `Display help for installed commands. Optionally include the command name for additional information.
+	LongHelp: `Display help for the commands built in to the current host's shell and the programs
+installed on it. Optionally include the command name for additional information.
 Usage: help [command]`,
 	Execute: func(state *State, args ...string) ([]byte, error) {
 		if len(args) > 1 {
 			return nil, fmt.Errorf("must supply zero or one arguments")
 		}
 
+		commands := state.availableCommands()
 		if len(args) == 0 {
 			buf := bytes.NewBufferString("Commands\n")
 			longest := 0
-			for cmdName := range state.Commands {
+			for cmdName := range commands {
 				if len(cmdName) > longest {
 					longest = len(cmdName)
 				}
 			}
 
-			for cmdName, cmd := range state.Commands {
+			for cmdName, cmd := range commands {
 				buf.WriteString("  ")
 				buf.WriteString(cmdName)
 				for i := 0; i < longest-len(cmdName); i++ {
@@ -68,7 +110,7 @@ Usage: help [command]`,
 			return buf.Bytes(), nil
 		}
 
-		cmd, ok := state.Commands[args[0]]
+		cmd, ok := commands[args[0]]
 		if !ok {
 			return nil, fmt.Errorf("unknown command: %s", args[0])
 		}
@@ -78,40 +120,173 @@ Usage: help [command]`,
 }
 
 var LSCommand = &Command{
-	ShortHelp: "List files in the current directory",
-	LongHelp: `List files in the current directory.
-Usage: ls`,
+	ShortHelp: "List files in a directory",
+	LongHelp: `List files in a directory, or the current directory if none is given.
+Flags:
+  -l  long listing with type, size and modification time
+  -a  include hidden files (names starting with a dot) and the current directory entry
+  -h  with -l, print sizes in human readable form (e.g. 1.5K)
+  -t  sort by modification time, newest first
+  -S  sort by size, largest first
+  -r  reverse the sort order
+Usage: ls [-lahtSr] [path]`,
 	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
 	Execute: func(state *State, args ...string) ([]byte, error) {
-		dirNames := []string{}
-		fileNames := []string{}
-		if state.CurrentDir.Parent != nil {
-			dirNames = append(dirNames, "..")
+		flags, paths, err := parseFlags(args, "lahtSr")
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) > 1 {
+			return nil, fmt.Errorf("must supply zero or one paths")
+		}
+
+		dir := state.CurrentDir
+		if len(paths) == 1 {
+			foundFile := state.FindFile(paths[0])
+			if foundFile == nil {
+				return nil, fmt.Errorf("file or directory not found")
+			}
+
+			if foundFile.Type() != fs.FileTypeDirectory {
+				return []byte(formatListing([]lsEntry{{name: foundFile.Name(), file: foundFile}}, flags)), nil
+			}
+			dir = foundFile.(*fs.Directory)
 		}
 
-		for _, f := range state.CurrentDir.Files {
+		dirEntries := []lsEntry{}
+		fileEntries := []lsEntry{}
+		for _, f := range dir.Files {
+			if isHidden(f.Name()) && !flags['a'] {
+				continue
+			}
+
 			if f.Type() == fs.FileTypeDirectory {
-				dirNames = append(dirNames, fmt.Sprintf("%s/", f.Name()))
+				dirEntries = append(dirEntries, lsEntry{name: f.Name() + "/", file: f})
 			} else {
-				fileNames = append(fileNames, f.Name())
+				fileEntries = append(fileEntries, lsEntry{name: f.Name(), file: f})
 			}
 		}
 
-		sort.Strings(dirNames)
-		sort.Strings(fileNames)
+		entries := []lsEntry{}
+		switch {
+		case flags['t']:
+			entries = append(dirEntries, fileEntries...)
+			sort.SliceStable(entries, func(i, j int) bool {
+				return entries[i].file.ModTime().After(entries[j].file.ModTime())
+			})
+		case flags['S']:
+			entries = append(dirEntries, fileEntries...)
+			sort.SliceStable(entries, func(i, j int) bool {
+				return entries[i].file.Size() > entries[j].file.Size()
+			})
+		default:
+			sortEntriesByName(dirEntries)
+			sortEntriesByName(fileEntries)
+			entries = append(dirEntries, fileEntries...)
+		}
+
+		if flags['r'] {
+			for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+		}
 
-		all := []string{}
-		all = append(all, dirNames...)
-		all = append(all, fileNames...)
+		// The navigation entries always stay at the top
+		special := []lsEntry{}
+		if flags['a'] {
+			special = append(special, lsEntry{name: ".", file: dir})
+		}
+		if parent := state.parentDir(dir); parent != nil {
+			special = append(special, lsEntry{name: "..", file: parent})
+		}
+		entries = append(special, entries...)
 
-		if len(all) == 0 {
-			return []byte("No files in the current directory"), nil
+		if len(entries) == 0 {
+			if dir == state.CurrentDir {
+				return []byte("No files in the current directory"), nil
+			}
+			return []byte(fmt.Sprintf("No files in %s", state.dirPath(dir))), nil
 		}
 
-		return []byte(strings.Join(all, "\n")), nil
+		return []byte(formatListing(entries, flags)), nil
 	},
 }
 
+type lsEntry struct {
+	name string
+	file fs.File
+}
+
+func sortEntriesByName(entries []lsEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+}
+
+func formatListing(entries []lsEntry, flags map[rune]bool) string {
+	if !flags['l'] {
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.name
+		}
+		return strings.Join(names, "\n")
+	}
+
+	sizes := make([]string, len(entries))
+	longestSize := 0
+	for i, e := range entries {
+		if flags['h'] {
+			sizes[i] = fs.HumanSize(e.file.Size())
+		} else {
+			sizes[i] = fmt.Sprintf("%d", e.file.Size())
+		}
+		if len(sizes[i]) > longestSize {
+			longestSize = len(sizes[i])
+		}
+	}
+
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		fileType := "-"
+		name := e.name
+		switch e.file.Type() {
+		case fs.FileTypeDirectory:
+			fileType = "d"
+		case fs.FileTypeExecutable:
+			fileType = "x"
+		case fs.FileTypeSymlink:
+			fileType = "l"
+			name = fmt.Sprintf("%s -> %s", name, e.file.(*fs.Symlink).Target)
+		}
+		lines[i] = fmt.Sprintf("%s %*s %s %s", fileType, longestSize, sizes[i], e.file.ModTime().Format("Jan 02 15:04"), name)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// parseFlags splits args into single letter flags (which may be combined,
+// e.g. -la) and the remaining positional arguments. Only flags found in
+// allowed are accepted.
+func parseFlags(args []string, allowed string) (map[rune]bool, []string, error) {
+	flags := map[rune]bool{}
+	rest := []string{}
+	for _, arg := range args {
+		if len(arg) < 2 || !strings.HasPrefix(arg, "-") {
+			rest = append(rest, arg)
+			continue
+		}
+
+		for _, c := range arg[1:] {
+			if !strings.ContainsRune(allowed, c) {
+				return nil, nil, fmt.Errorf("unknown flag: -%c", c)
+			}
+			flags[c] = true
+		}
+	}
+
+	return flags, rest, nil
+}
+
 var MKDIRCommand = &Command{
 	ShortHelp: "Create a new directory",
 	LongHelp: `Create a new directory. New folders can only use alphanumeric characters separated by dashes or underscores. No spaces.
@@ -130,20 +305,22 @@ Usage: mkdir [name]`,
 		if _, ok := state.CurrentDir.Files[dirNameLower]; ok {
 			return nil, fmt.Errorf("file or directory with that name already exists")
 		}
-
-		state.CurrentDir.Files[dirNameLower] = &fs.Directory{
-			Parent:  state.CurrentDir,
-			DirName: dirName,
-			Files:   map[string]fs.File{},
+		if err := state.checkWritable(state.CurrentDir); err != nil {
+			return nil, err
 		}
 
+		now := state.Clock.Now()
+		state.CurrentDir.Files[dirNameLower] = fs.NewDirectory(state.CurrentDir, dirName, now)
+		state.CurrentDir.Modified = now
+
 		return nil, nil
 	},
 }
 
 var CDCommand = &Command{
 	ShortHelp: "Change directory",
-	LongHelp: `Change directory.
+	LongHelp: `Change directory. With no arguments, or ~, change to your home directory.
+Use - to return to the previous directory.
 Usage: cd [relative or absolute path to directory]`,
 	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
 	Execute: func(state *State, args ...string) ([]byte, error) {
@@ -151,16 +328,31 @@ Usage: cd [relative or absolute path to directory]`,
 			return nil, fmt.Errorf("must supply zero or one arguments")
 		}
 
-		if len(args) == 0 || args[0] == ".." {
-			if state.CurrentDir.Parent == nil {
+		if len(args) == 0 {
+			state.ChangeDir(state.HomeDir())
+			return nil, nil
+		}
+
+		if args[0] == "-" {
+			if state.PreviousDir == nil {
+				return nil, fmt.Errorf("no previous directory")
+			}
+
+			state.ChangeDir(state.PreviousDir)
+			return []byte(state.dirPath(state.CurrentDir)), nil
+		}
+
+		if args[0] == ".." {
+			parent := state.parentDir(state.CurrentDir)
+			if parent == nil {
 				return nil, fmt.Errorf("cannot go up a directory")
 			}
 
-			state.CurrentDir = state.CurrentDir.Parent
+			state.ChangeDir(parent)
 			return nil, nil
 		}
 
-		foundFile := fs.FindFileRelative(state.CurrentDir, state.CurrentHost.RootDir, args[0])
+		foundFile := state.FindFile(args[0])
 		if foundFile == nil {
 			return nil, fmt.Errorf("directory not found")
 		}
@@ -169,7 +361,7 @@ Usage: cd [relative or absolute path to directory]`,
 			return nil, fmt.Errorf("that is not a directory")
 		}
 
-		state.CurrentDir = foundFile.(*fs.Directory)
+		state.ChangeDir(foundFile.(*fs.Directory))
 
 		return nil, nil
 	},
@@ -185,8 +377,9 @@ Usage: exit`,
 			return nil, nil
 		}
 
-		state.CurrentHost = state.LocalHost
-		state.CurrentDir = state.LocalHost.RootDir
+		state.Login(state.LocalHost, state.LocalUser)
+		state.Chain = nil
+		state.updateTrace()
 
 		return []byte("Disconnected."), nil
 	},
@@ -202,22 +395,73 @@ Usage: rmdir [directory name]`,
 			return nil, fmt.Errorf("must supply directory name")
 		}
 
-		foundFile := fs.FindFileRelative(state.CurrentDir, state.CurrentHost.RootDir, args[0])
+		foundFile := state.FindFileNoFollow(args[0])
 		if foundFile == nil {
 			return nil, fmt.Errorf("directory not found")
 		}
 
+		if foundFile.Type() == fs.FileTypeSymlink {
+			return nil, fmt.Errorf("that is a symlink, use rm")
+		}
 		if foundFile.Type() != fs.FileTypeDirectory {
 			return nil, fmt.Errorf("that is not a directory")
 		}
 
-		// Remove this file from its parent dir
 		dir := foundFile.(*fs.Directory)
-		if dir.Parent == nil {
+		if state.mountedBeneath(dir) {
+			return nil, fmt.Errorf("%s: device or resource busy", args[0])
+		}
+		if dir == state.CurrentHost.RootDir {
 			return nil, fmt.Errorf("cannot rmdir root")
 		}
 
-		delete(dir.Parent.Files, args[0])
+		// Remove this directory from the one its path names, which may
+		// not be its parent if the path went through . or ..
+		parent, ok := state.FindFile(path.Dir(args[0])).(*fs.Directory)
+		name := strings.ToLower(dir.DirName)
+		if !ok || parent.Files[name] != dir {
+			return nil, fmt.Errorf("%s: invalid argument", args[0])
+		}
+		if err := state.checkWritable(parent); err != nil {
+			return nil, err
+		}
+
+		delete(parent.Files, name)
+		parent.Modified = state.Clock.Now()
+
+		return nil, nil
+	},
+}
+
+var RMCommand = &Command{
+	ShortHelp: "Remove a file",
+	LongHelp: `Remove a file or symlink. Use rmdir to remove directories.
+Usage: rm [file name]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply file name")
+		}
+
+		foundFile := state.FindFileNoFollow(args[0])
+		if foundFile == nil {
+			return nil, fmt.Errorf("file not found")
+		}
+
+		if foundFile.Type() == fs.FileTypeDirectory {
+			return nil, fmt.Errorf("that is a directory, use rmdir")
+		}
+
+		dir, ok := state.FindFile(path.Dir(args[0])).(*fs.Directory)
+		if !ok {
+			return nil, fmt.Errorf("file not found")
+		}
+		if err := state.checkWritable(dir); err != nil {
+			return nil, err
+		}
+
+		delete(dir.Files, strings.ToLower(foundFile.Name()))
+		dir.Modified = state.Clock.Now()
 
 		return nil, nil
 	},
@@ -235,34 +479,29 @@ Usage: create [filename] "your text here"`,
 
 		filePath := args[0]
 
+		if len(args) > 1 {
+			if err := state.reserve(state.dirOf(filePath), int64(len(args[1]))); err != nil {
+				return nil, err
+			}
+		}
+
 		var textFile *fs.Text
 		var createdNew bool
-		foundFile := fs.FindFileRelative(state.CurrentDir, state.CurrentHost.RootDir, filePath)
+		foundFile := state.FindFile(filePath)
 
 		if foundFile == nil {
 			// we must create the new file
-			pathParts := strings.Split(filePath, "/")
-			newFilename := filePath
-
-			creatingInDir := state.CurrentDir
-			if strings.HasSuffix(filePath, "/") || len(pathParts) > 1 {
-				newFilename = pathParts[len(pathParts)-1]
-				// This is a path to a file, we must first find the directory referenced
-				foundDir := fs.FindFileRelative(state.CurrentDir, state.CurrentHost.RootDir, strings.TrimSuffix(filePath, newFilename))
-				if foundDir == nil || foundDir.Type() != fs.FileTypeDirectory {
-					return nil, fmt.Errorf("file path not valid - directory does not exist")
-				}
-				creatingInDir = foundDir.(*fs.Directory)
-			}
-
-			if err := fs.ValidateFileName(newFilename); err != nil {
+			creatingInDir, newFilename, err := state.findNewFilePath(filePath)
+			if err != nil {
 				return nil, err
 			}
 
 			createdNew = true
 
-			creatingInDir.Files[newFilename] = &fs.Text{FileName: newFilename}
-			textFile = creatingInDir.Files[newFilename].(*fs.Text)
+			textFile = fs.NewText(newFilename, nil, state.Clock.Now())
+			if err := state.addFile(creatingInDir, textFile); err != nil {
+				return nil, err
+			}
 		} else if foundFile.Type() != fs.FileTypeText {
 			return nil, fmt.Errorf("cannot append to non-text file")
 		} else {
@@ -271,6 +510,7 @@ Usage: create [filename] "your text here"`,
 
 		if len(args) > 1 && args[1] != "" {
 			textFile.Contents = append(textFile.Contents, []byte(args[1])...)
+			textFile.Modified = state.Clock.Now()
 		}
 
 		if createdNew {
@@ -293,18 +533,39 @@ Usage: cat [path to file]`,
 
 		filePath := args[0]
 
-		foundFile := fs.FindFileRelative(state.CurrentDir, state.CurrentHost.RootDir, filePath)
-		if foundFile == nil {
-			return nil, fmt.Errorf("file not found")
-		}
-		if foundFile.Type() != fs.FileTypeText {
-			return nil, fmt.Errorf("%v is not a readable file", filePath)
-		}
-
-		return foundFile.(*fs.Text).Contents, nil
+		return readFile(state, filePath)
 	},
 }
 
+// readFile returns what reading the file at path shows. Encrypted files show
+// their ciphertext, and binary files a warning rather than their bytes.
+func readFile(state *State, path string) ([]byte, error) {
+	file, data, err := fileData(state, path)
+	if err != nil {
+		return nil, err
+	}
+	if file.Type() == fs.FileTypeBinary {
+		return []byte(fmt.Sprintf("%s is a binary file (%d bytes), use hexdump or strings to inspect it", path, len(data))), nil
+	}
+	return data, nil
+}
+
+// fileData returns the raw bytes of the file at path
+func fileData(state *State, path string) (fs.File, []byte, error) {
+	switch f := state.FindFile(path).(type) {
+	case nil:
+		return nil, nil, fmt.Errorf("file not found")
+	case *fs.Text:
+		return f, f.Contents, nil
+	case *fs.Binary:
+		return f, f.Data, nil
+	case *fs.Encrypted:
+		return f, f.Ciphertext(), nil
+	}
+
+	return nil, nil, fmt.Errorf("%v is not a readable file", path)
+}
+
 var ReplaceCommand = &Command{
 	ShortHelp: "Replace all instances of a string in a file",
 	LongHelp: `Replace all instances of a string in a file.
@@ -317,7 +578,7 @@ Usage: replace [path to file] [search text] [replace text]`,
 
 		filePath := args[0]
 
-		foundFile := fs.FindFileRelative(state.CurrentDir, state.CurrentHost.RootDir, filePath)
+		foundFile := state.FindFile(filePath)
 		if foundFile == nil {
 			return nil, fmt.Errorf("file not found")
 		}
@@ -327,26 +588,57 @@ Usage: replace [path to file] [search text] [replace text]`,
 
 		file := foundFile.(*fs.Text)
 		new := strings.Replace(string(file.Contents), args[1], args[2], -1)
+		if err := state.reserve(state.dirOf(filePath), int64(len(new))-file.Size()); err != nil {
+			return nil, err
+		}
 
 		file.Contents = []byte(new)
+		file.Modified = state.Clock.Now()
 
 		return nil, nil
 	},
 }
 
 var ScanCommand = &Command{
-	ShortHelp: "Scan other hosts connected to the current hosts",
-	LongHelp: `Scan other hosts connected to the current hosts.
+	ShortHelp: "Scan for other hosts reachable from the current host",
+	LongHelp: `Scan for other hosts reachable from the current host, and how many hops away they are.
+Hosts whose firewall blocks the scan are shown as filtered.
 Usage: scan`,
 	Execute: func(state *State, args ...string) ([]byte, error) {
-		if len(state.CurrentHost.ConnectedHosts) == 0 {
+		reachable := state.Network.ReachableHosts(state.CurrentHost)
+		if len(reachable) == 0 {
 			return []byte("No connected hosts."), nil
 		}
 
+		// Discover nearer hosts first so further ones hang off them on the map
+		hosts := []*Host{}
+		for host := range reachable {
+			hosts = append(hosts, host)
+		}
+		sort.Slice(hosts, func(i, j int) bool {
+			return len(reachable[hosts[i]]) < len(reachable[hosts[j]])
+		})
+
 		otherHosts := []string{}
-		for host := range state.CurrentHost.ConnectedHosts {
-			otherHosts = append(otherHosts, host)
+		for _, host := range hosts {
+			hops := reachable[host]
+			state.discover(state.CurrentHost, host, hops)
+
+			name := host.Hostname
+			if host.DomainName != "" {
+				name = fmt.Sprintf("%s %s", host.Hostname, host.DomainName)
+			}
+
+			line := fmt.Sprintf("%s (%d hops)", name, len(hops))
+			if len(hops) == 1 {
+				line = fmt.Sprintf("%s (1 hop)", name)
+			}
+			if state.checkFirewall(host, 0) != nil {
+				line += " filtered"
+			}
+			otherHosts = append(otherHosts, line)
 		}
+		sort.Strings(otherHosts)
 
 		return []byte(strings.Join(otherHosts, "\n")), nil
 	},
@@ -354,22 +646,59 @@ Usage: scan`,
 
 var ConnectCommand = &Command{
 	ShortHelp: "Connect to another host",
-	LongHelp: `Connect to another host.
-Usage: connect [hostname]`,
+	LongHelp: `Connect to another host, optionally as a specific user. Defaults to root.
+The host must be running an ssh service. Users with passwords need the password, either
+given here or saved in ~/.keyring, where passwords that work are kept.
+Usage: connect [user@]hostname, DNS name or IP [password]`,
 	TabCompletionTypes: []TabCompletionType{TabCompletionTypeServer},
 	Execute: func(state *State, args ...string) ([]byte, error) {
-		if len(args) != 1 {
-			return nil, fmt.Errorf("must supply a hostname")
+		if len(args) != 1 && len(args) != 2 {
+			return nil, fmt.Errorf("must supply a hostname and optionally a password")
 		}
 
-		host, ok := state.CurrentHost.ConnectedHosts[args[0]]
+		userName, hostname := "root", args[0]
+		if idx := strings.Index(hostname, "@"); idx >= 0 {
+			userName, hostname = hostname[:idx], hostname[idx+1:]
+		}
+
+		host, hops, err := state.routeTo(hostname)
+		if err != nil {
+			return nil, err
+		}
+
+		ssh := host.ServiceOfType(ServiceTypeSSH)
+		if ssh == nil {
+			return nil, fmt.Errorf("connection to %s refused: no ssh service running", host.Hostname)
+		}
+
+		if err := state.checkFirewall(host, ssh.Port); err != nil {
+			return nil, err
+		}
+
+		user, ok := host.Users[userName]
 		if !ok {
-			return nil, fmt.Errorf("host %s not found", args[0])
+			return nil, fmt.Errorf("no such user %s on %s", userName, host.Hostname)
 		}
 
-		state.CurrentHost = host
-		state.CurrentDir = host.RootDir
+		password := ""
+		if len(args) == 2 {
+			password = args[1]
+		}
+		if err := state.authenticate(host, user, password); err != nil {
+			return nil, err
+		}
+
+		state.discover(state.CurrentHost, host, hops)
+		host.audit(state.Clock.Now(), state.CurrentHost, user.Name, "connect")
+		state.Login(host, user)
+		state.Chain = append(state.Chain, host)
+		state.updateTrace()
+
+		output := []byte(fmt.Sprintf("connected to %s:%d as %s", host.Hostname, ssh.Port, user.Name))
+		if rcOutput := state.runShellRC(); len(rcOutput) > 0 {
+			output = append(append(output, '\n'), rcOutput...)
+		}
 
-		return []byte(fmt.Sprintf("connected to %s", host.Hostname)), nil
+		return output, nil
 	},
 }
@@ -0,0 +1,264 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// crontabPath is the file each host reads its scheduled jobs from
+	crontabPath = "/etc/crontab"
+	// cronLogPath is where hosts record the jobs they have run
+	cronLogPath = "/var/log/cron.log"
+)
+
+// interactiveCommands change the player's session or take over the screen,
+// so can't be run by cron jobs or in the background, where they would act on
+// a copy of the session nobody is looking at.
+var interactiveCommands = map[string]bool{
+	"exit":     true,
+	"connect":  true,
+	"cd":       true,
+	"scan":     true,
+	"portscan": true,
+	"less":     true,
+	"more":     true,
+	"pager":    true,
+	"map":      true,
+	"clock":    true,
+	"jobs":     true,
+	"fg":       true,
+	"crack":    true,
+}
+
+// cronField is one of the time fields of a crontab line, holding the values
+// it matches.
+type cronField struct {
+	values map[int]bool
+	// any is set for a bare *, which matters for day matching
+	any bool
+}
+
+func (f cronField) matches(value int) bool {
+	return f.any || f.values[value]
+}
+
+// parseCronField parses a comma separated list of *, n or n-m, each with an
+// optional /step, for values between min and max.
+func parseCronField(field string, min, max int) (cronField, error) {
+	f := cronField{values: map[int]bool{}, any: field == "*"}
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return f, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			loPart, hiPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(loPart); err != nil {
+				return f, fmt.Errorf("invalid value %q", loPart)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiPart); err != nil {
+					return f, fmt.Errorf("invalid value %q", hiPart)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return f, fmt.Errorf("%q out of range", item)
+		}
+
+		for v := lo; v <= hi; v += step {
+			f.values[v] = true
+		}
+	}
+	return f, nil
+}
+
+// CronJob is a shell line a host runs as a user whenever the time matches
+type CronJob struct {
+	minute, hour, dom, month, dow cronField
+
+	User    string
+	Command string
+}
+
+// parseCronLine parses a system crontab line: minute, hour, day of month,
+// month and day of week, followed by the user and the command to run.
+func parseCronLine(line string) (*CronJob, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 7 {
+		return nil, fmt.Errorf("expected five time fields, a user and a command")
+	}
+
+	job := &CronJob{User: fields[5], Command: strings.Join(fields[6:], " ")}
+	var err error
+	if job.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if job.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if job.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if job.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	// Both 0 and 7 are Sunday
+	if job.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	if job.dow.values[7] {
+		job.dow.values[0] = true
+	}
+
+	return job, nil
+}
+
+// due reports whether the job should run in the minute starting at t
+func (j *CronJob) due(t time.Time) bool {
+	if !j.minute.matches(t.Minute()) || !j.hour.matches(t.Hour()) || !j.month.matches(int(t.Month())) {
+		return false
+	}
+
+	// As in cron, if both days are restricted either one will do
+	dom, dow := j.dom.matches(t.Day()), j.dow.matches(int(t.Weekday()))
+	if !j.dom.any && !j.dow.any {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// cronJobs reads the jobs in a host's crontab, skipping lines it can't parse
+func cronJobs(host *Host) []*CronJob {
+	jobs := []*CronJob{}
+	for _, line := range readConfigFile(host, crontabPath) {
+		if job, err := parseCronLine(line); err == nil {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// startCron runs every host's due cron jobs at the start of each simulated
+// minute from now on.
+func (s *State) startCron() {
+	next := s.Clock.Now().Truncate(time.Minute).Add(time.Minute)
+	s.Clock.At(next, func() {
+		// Book the next minute from the clock rather than the event loop,
+		// so none are missed however far the clock jumps
+		s.startCron()
+		s.callback(func() { s.runCron(next) })
+	})
+}
+
+// runCron runs the jobs due at t on every host. Crontabs are read afresh
+// each time so changes made to them take effect. Missions are then checked
+// from the player's session, as the jobs may have changed files they watch.
+func (s *State) runCron(t time.Time) {
+	for _, host := range s.Network.Hosts {
+		for _, job := range cronJobs(host) {
+			if job.due(t) {
+				s.runCronJob(host, job, t)
+			}
+		}
+	}
+	s.checkMissions()
+}
+
+// runCronJob runs a job's command in a session of its own on the host,
+// leaving the player where they are. Output is thrown away, but the host
+// logs each run.
+func (s *State) runCronJob(host *Host, job *CronJob, t time.Time) {
+	entry := fmt.Sprintf("%s %s CRON[%s]: CMD (%s)", t.Format(auditTimeFormat), host.Hostname, job.User, job.Command)
+
+	user, ok := host.Users[job.User]
+	if !ok {
+		host.appendFile(cronLogPath, entry+" failed: no such user\n", t)
+		return
+	}
+
+	session := *s
+	session.Login(host, user)
+	session.Chain = nil
+	session.background = true
+
+	if _, err := session.Run(job.Command); err != nil {
+		entry += fmt.Sprintf(" failed: %v", err)
+	}
+	host.appendFile(cronLogPath, entry+"\n", t)
+}
+
+var CrontabCommand = &Command{
+	ShortHelp: "List or change the current host's cron jobs",
+	LongHelp: `List the jobs in the current host's /etc/crontab, add one or remove one by number. Jobs
+are written as minute, hour, day of month, month and day of week, then the user to run as
+and the command, e.g. "*/5 * * * * root rm /var/log/audit.log". Only root can change them.
+Usage: crontab [add "job"|remove n]`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		host := state.CurrentHost
+		if len(args) == 0 {
+			lines := readConfigFile(host, crontabPath)
+			if len(lines) == 0 {
+				return []byte("No cron jobs."), nil
+			}
+
+			buf := &bytes.Buffer{}
+			for i, line := range lines {
+				buf.WriteString(fmt.Sprintf("%3d  %s\n", i+1, line))
+			}
+			return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+		}
+
+		if state.CurrentUser.Name != "root" {
+			return nil, fmt.Errorf("only root can change cron jobs")
+		}
+
+		switch args[0] {
+		case "add":
+			if len(args) != 2 {
+				return nil, fmt.Errorf("must supply the job in quotes")
+			}
+			if _, err := parseCronLine(args[1]); err != nil {
+				return nil, fmt.Errorf("invalid job: %v", err)
+			}
+
+			host.appendFile(crontabPath, args[1]+"\n", state.Clock.Now())
+			return []byte("job added"), nil
+		case "remove":
+			if len(args) != 2 {
+				return nil, fmt.Errorf("must supply a job number")
+			}
+			lines := readConfigFile(host, crontabPath)
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 || n > len(lines) {
+				return nil, fmt.Errorf("no job %s", args[1])
+			}
+
+			lines = append(lines[:n-1], lines[n:]...)
+			contents := ""
+			for _, line := range lines {
+				contents += line + "\n"
+			}
+			if err := host.WriteFile(crontabPath, contents, state.Clock.Now()); err != nil {
+				return nil, err
+			}
+			return []byte("job removed"), nil
+		}
+
+		return nil, fmt.Errorf("unknown crontab command: %s", args[0])
+	},
+}
@@ -0,0 +1,207 @@
+package command
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ckiely91/shellsim/clock"
+	"github.com/ckiely91/shellsim/fs"
+)
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     []int
+		any      bool
+		wantErr  bool
+	}{
+		{field: "*", min: 0, max: 5, want: []int{0, 1, 2, 3, 4, 5}, any: true},
+		{field: "3", min: 0, max: 59, want: []int{3}},
+		{field: "1,4,9", min: 0, max: 59, want: []int{1, 4, 9}},
+		{field: "10-13", min: 0, max: 59, want: []int{10, 11, 12, 13}},
+		{field: "*/15", min: 0, max: 59, want: []int{0, 15, 30, 45}},
+		{field: "1-10/3", min: 0, max: 59, want: []int{1, 4, 7, 10}},
+		{field: "50/5", min: 0, max: 59, want: []int{50, 55}},
+		{field: "1-3,20-21", min: 0, max: 59, want: []int{1, 2, 3, 20, 21}},
+		{field: "0", min: 0, max: 59, want: []int{0}},
+		{field: "59", min: 0, max: 59, want: []int{59}},
+
+		{field: "60", min: 0, max: 59, wantErr: true},
+		{field: "-1", min: 0, max: 59, wantErr: true},
+		{field: "0", min: 1, max: 31, wantErr: true},
+		{field: "32", min: 1, max: 31, wantErr: true},
+		{field: "13", min: 1, max: 12, wantErr: true},
+		{field: "20-60", min: 0, max: 59, wantErr: true},
+		{field: "9-3", min: 0, max: 59, wantErr: true},
+		{field: "*/0", min: 0, max: 59, wantErr: true},
+		{field: "*/x", min: 0, max: 59, wantErr: true},
+		{field: "x", min: 0, max: 59, wantErr: true},
+		{field: "1,", min: 0, max: 59, wantErr: true},
+		{field: "", min: 0, max: 59, wantErr: true},
+	}
+	for _, tt := range tests {
+		f, err := parseCronField(tt.field, tt.min, tt.max)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: no error", tt.field)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.field, err)
+			continue
+		}
+
+		got := []int{}
+		for v := range f.values {
+			got = append(got, v)
+		}
+		sort.Ints(got)
+		if !equalInts(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.field, got, tt.want)
+		}
+		if f.any != tt.any {
+			t.Errorf("%q: any = %v, want %v", tt.field, f.any, tt.any)
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCronJobDue(t *testing.T) {
+	// 2031-03-14 is a Friday, 2031-03-16 a Sunday and 2031-03-01 a Saturday
+	friday := time.Date(2031, time.March, 14, 9, 30, 0, 0, time.UTC)
+	sunday := time.Date(2031, time.March, 16, 9, 30, 0, 0, time.UTC)
+	first := time.Date(2031, time.March, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		line string
+		t    time.Time
+		want bool
+	}{
+		{"* * * * * root true", friday, true},
+		{"30 9 * * * root true", friday, true},
+		{"31 9 * * * root true", friday, false},
+		{"30 10 * * * root true", friday, false},
+		{"*/15 * * * * root true", friday, true},
+		{"*/20 * * * * root true", friday, false},
+		{"* * * 4 * root true", friday, false},
+
+		// Day of week alone
+		{"* * * * 5 root true", friday, true},
+		{"* * * * 1-4 root true", friday, false},
+		{"* * * * 0 root true", sunday, true},
+		{"* * * * 7 root true", sunday, true},
+		{"* * * * 1-5 root true", sunday, false},
+
+		// Day of month alone
+		{"* * 14 * * root true", friday, true},
+		{"* * 15 * * root true", friday, false},
+
+		// When both are restricted, either will do
+		{"* * 1 * 5 root true", friday, true},
+		{"* * 1 * 5 root true", first, true},
+		{"* * 1 * 5 root true", sunday, false},
+
+		// When only one is restricted, it must match
+		{"* * 1 * * root true", friday, false},
+		{"* * */2 * * root true", sunday, false},
+		{"* * * * 6 root true", first, true},
+		{"* * * * 6 root true", friday, false},
+	}
+	for _, tt := range tests {
+		job, err := parseCronLine(tt.line)
+		if err != nil {
+			t.Errorf("%q: %v", tt.line, err)
+			continue
+		}
+		if got := job.due(tt.t); got != tt.want {
+			t.Errorf("%q at %s: due = %v, want %v", tt.line, tt.t.Format("Mon Jan 2 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestParseCronLine(t *testing.T) {
+	job, err := parseCronLine("0 * * * * root rm /var/log/audit.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.User != "root" || job.Command != "rm /var/log/audit.log" {
+		t.Fatalf("got user %q command %q", job.User, job.Command)
+	}
+
+	for _, line := range []string{
+		"",
+		"* * * * * root",
+		"* * * * root true",
+		"60 * * * * root true",
+		"* 24 * * * root true",
+		"* * 0 * * root true",
+		"* * * 13 * root true",
+		"* * * * 8 root true",
+		"a b c d e root true",
+	} {
+		if _, err := parseCronLine(line); err == nil {
+			t.Errorf("%q: no error", line)
+		}
+	}
+}
+
+func TestCronJobsSkipMalformedLines(t *testing.T) {
+	host := NewHost("cronhost", clock.Epoch)
+	host.WriteFile(crontabPath, strings.Join([]string{
+		"# m h dom mon dow user command",
+		"0 * * * * root rm /var/log/audit.log",
+		"not a job",
+		"61 * * * * root true",
+		"* * * * * root",
+		"*/5 * * * * admin ls /home",
+		"",
+	}, "\n"), clock.Epoch)
+
+	jobs := cronJobs(host)
+	if len(jobs) != 2 {
+		t.Fatalf("got %d jobs, want 2", len(jobs))
+	}
+	if jobs[0].Command != "rm /var/log/audit.log" || jobs[1].User != "admin" {
+		t.Fatalf("got %+v and %+v", jobs[0], jobs[1])
+	}
+}
+
+func TestCronRunsOnFakeClock(t *testing.T) {
+	clk := clock.NewFake(clock.Epoch)
+	network, localHost := defaultWorld(clk.Now())
+	state := NewStateWithNetwork(clk, network, localHost)
+	localHost.WriteFile(crontabPath, "* * * * * root mkdir ran\n5 * * * * root mkdir later\n", clk.Now())
+
+	// Nothing is running the event loop, so this would block if the jobs
+	// went through it
+	clk.Advance(time.Minute)
+
+	if _, ok := fs.FindFileRelative(localHost.RootDir, localHost.RootDir, "/root/ran").(*fs.Directory); !ok {
+		t.Fatal("the job due every minute didn't run")
+	}
+	if fs.FindFileRelative(localHost.RootDir, localHost.RootDir, "/root/later") != nil {
+		t.Fatal("the job due at five past ran early")
+	}
+	log, ok := fs.FindFileRelative(localHost.RootDir, localHost.RootDir, cronLogPath).(*fs.Text)
+	if !ok || !strings.Contains(string(log.Contents), "CRON[root]: CMD (mkdir ran)") {
+		t.Fatalf("the run wasn't logged")
+	}
+	if state.CurrentHost != localHost {
+		t.Fatal("the job moved the player")
+	}
+}
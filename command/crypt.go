@@ -0,0 +1,88 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+// findEntry finds the directory holding path and the entry for it there,
+// without following a symbolic link at the end.
+func (s *State) findEntry(path string) (*fs.Directory, fs.File, error) {
+	dir := s.CurrentDir
+	name := path
+	if idx := strings.LastIndex(path, "/"); idx >= 0 {
+		name = path[idx+1:]
+		foundDir, ok := s.FindFile(path[:idx+1]).(*fs.Directory)
+		if !ok {
+			return nil, nil, fmt.Errorf("file not found")
+		}
+		dir = foundDir
+	}
+
+	file, ok := dir.Files[strings.ToLower(name)]
+	if !ok {
+		return nil, nil, fmt.Errorf("file not found")
+	}
+	return dir, file, nil
+}
+
+var EncryptCommand = &Command{
+	ShortHelp: "Encrypt a file with a key",
+	LongHelp: `Encrypt a text file in place with a key. It can only be read again once decrypted with the
+same key.
+Usage: encrypt [path to file] [key]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("must supply a file path and a key")
+		}
+
+		dir, file, err := state.findEntry(args[0])
+		if err != nil {
+			return nil, err
+		}
+		text, ok := file.(*fs.Text)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a text file", args[0])
+		}
+
+		if err := state.addFile(dir, fs.NewEncrypted(text.Name(), text.Contents, args[1], state.Clock.Now())); err != nil {
+			return nil, err
+		}
+		return []byte(fmt.Sprintf("encrypted %s", args[0])), nil
+	},
+}
+
+var DecryptCommand = &Command{
+	ShortHelp: "Decrypt a file with its key",
+	LongHelp: `Decrypt an encrypted file in place, turning it back into a readable text file. Only the key
+it was encrypted with will do.
+Usage: decrypt [path to file] [key]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("must supply a file path and a key")
+		}
+
+		dir, file, err := state.findEntry(args[0])
+		if err != nil {
+			return nil, err
+		}
+		encrypted, ok := file.(*fs.Encrypted)
+		if !ok {
+			return nil, fmt.Errorf("%s is not encrypted", args[0])
+		}
+
+		contents, err := encrypted.Decrypt(args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		if err := state.addFile(dir, fs.NewText(encrypted.Name(), contents, state.Clock.Now())); err != nil {
+			return nil, err
+		}
+		return []byte(fmt.Sprintf("decrypted %s", args[0])), nil
+	},
+}
@@ -0,0 +1,266 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+const (
+	hostsFilePath      = "/etc/hosts"
+	resolvConfFilePath = "/etc/resolv.conf"
+)
+
+// AddDNSRecord adds a name to the zone served by a DNS server host
+func (h *Host) AddDNSRecord(name string, ip net.IP) {
+	h.DNSRecords[strings.ToLower(name)] = ip
+}
+
+// readConfigFile returns the lines of a config file on a host, or nothing if
+// it does not exist.
+func readConfigFile(host *Host, path string) []string {
+	file, ok := fs.FindFileRelative(host.RootDir, host.RootDir, path).(*fs.Text)
+	if !ok {
+		return nil
+	}
+	return configLines(file.Contents)
+}
+
+// hostsFileEntries reads the host's /etc/hosts into a map of name to IP
+func hostsFileEntries(host *Host) map[string]net.IP {
+	entries := map[string]net.IP{}
+	for _, line := range readConfigFile(host, hostsFilePath) {
+		fields := strings.Fields(line)
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			continue
+		}
+		for _, name := range fields[1:] {
+			entries[strings.ToLower(name)] = ip
+		}
+	}
+	return entries
+}
+
+// nameservers reads the nameserver addresses from the host's resolv.conf
+func nameservers(host *Host) []string {
+	servers := []string{}
+	for _, line := range readConfigFile(host, resolvConfFilePath) {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
+
+// nameserverZone returns the records served by the DNS server at address.
+// The server must be reachable and running a DNS service its firewall lets
+// us reach.
+func (s *State) nameserverZone(address string) (map[string]net.IP, error) {
+	// Nameservers are never looked up by name, which could recurse forever
+	if net.ParseIP(address) == nil {
+		return nil, fmt.Errorf("nameserver %s is not an IP", address)
+	}
+
+	server, err := s.findHost(address)
+	if err != nil {
+		return nil, err
+	}
+
+	dns := server.ServiceOfType(ServiceTypeDNS)
+	if dns == nil {
+		return nil, fmt.Errorf("no DNS service running on %s", address)
+	}
+
+	if err := s.checkFirewall(server, dns.Port); err != nil {
+		return nil, err
+	}
+
+	return server.DNSRecords, nil
+}
+
+// queryNameserver asks the DNS server at address to resolve name
+func (s *State) queryNameserver(address, name string) (net.IP, error) {
+	zone, err := s.nameserverZone(address)
+	if err != nil {
+		return nil, err
+	}
+
+	ip, ok := zone[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("server can't find %s: NXDOMAIN", name)
+	}
+	return ip, nil
+}
+
+// resolve looks up a name from the current host, first in /etc/hosts and
+// then by asking each nameserver in /etc/resolv.conf in turn. It returns the
+// IP along with where the answer came from.
+func (s *State) resolve(name string) (net.IP, string, error) {
+	if ip := net.ParseIP(name); ip != nil {
+		return ip, "", nil
+	}
+
+	if ip, ok := hostsFileEntries(s.CurrentHost)[strings.ToLower(name)]; ok {
+		return ip, hostsFilePath, nil
+	}
+
+	servers := nameservers(s.CurrentHost)
+	if len(servers) == 0 {
+		return nil, "", fmt.Errorf("could not resolve %s: no nameservers configured", name)
+	}
+
+	var lastErr error
+	for _, server := range servers {
+		ip, err := s.queryNameserver(server, name)
+		if err == nil {
+			return ip, server, nil
+		}
+		lastErr = err
+	}
+
+	return nil, "", fmt.Errorf("could not resolve %s: %v", name, lastErr)
+}
+
+// resolveAddress turns a hostname, DNS name or IP into an address that can
+// be looked up in the network.
+func (s *State) resolveAddress(address string) (string, error) {
+	if net.ParseIP(address) != nil || s.Network.HostByAddress(address) != nil {
+		return address, nil
+	}
+
+	ip, _, err := s.resolve(address)
+	if err != nil {
+		return "", err
+	}
+	return ip.String(), nil
+}
+
+// knownNames returns the names the current host can resolve without asking
+// a nameserver, plus the DNS names of reachable hosts, for tab completion.
+func (s *State) knownNames() []string {
+	names := []string{}
+	for name := range hostsFileEntries(s.CurrentHost) {
+		names = append(names, name)
+	}
+	for host := range s.Network.ReachableHosts(s.CurrentHost) {
+		names = append(names, host.Hostname)
+		if host.DomainName != "" {
+			names = append(names, host.DomainName)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reverseLookup finds a name for ip in /etc/hosts or a nameserver's zone
+func (s *State) reverseLookup(ip net.IP) (string, string, error) {
+	for name, entryIP := range hostsFileEntries(s.CurrentHost) {
+		if entryIP.Equal(ip) {
+			return name, hostsFilePath, nil
+		}
+	}
+
+	for _, address := range nameservers(s.CurrentHost) {
+		zone, err := s.nameserverZone(address)
+		if err != nil {
+			continue
+		}
+
+		names := []string{}
+		for name, recordIP := range zone {
+			if recordIP.Equal(ip) {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			return names[0], address, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("server can't find %s: NXDOMAIN", ip)
+}
+
+var NSLookupCommand = &Command{
+	ShortHelp: "Look up the IP of a name, or the name of an IP",
+	LongHelp: `Look up the IP of a name, or the name of an IP, using /etc/hosts and then the
+nameservers listed in /etc/resolv.conf.
+Usage: nslookup [name or IP]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeServer},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a name or IP")
+		}
+
+		if ip := net.ParseIP(args[0]); ip != nil {
+			name, server, err := state.reverseLookup(ip)
+			if err != nil {
+				return nil, err
+			}
+			return []byte(fmt.Sprintf("Server:  %s\n%s\tname = %s", server, ip, name)), nil
+		}
+
+		ip, server, err := state.resolve(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(fmt.Sprintf("Server:  %s\nName:    %s\nAddress: %s", server, args[0], ip)), nil
+	},
+}
+
+var DigCommand = &Command{
+	ShortHelp: "Query DNS for a name",
+	LongHelp: `Query DNS for a name. Unlike nslookup, /etc/hosts is skipped. Use @server to ask a
+specific nameserver instead of those in /etc/resolv.conf.
+Usage: dig [@server] [name]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeServer},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		servers := nameservers(state.CurrentHost)
+		names := []string{}
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "@") {
+				servers = []string{strings.TrimPrefix(arg, "@")}
+			} else {
+				names = append(names, arg)
+			}
+		}
+		if len(names) != 1 {
+			return nil, fmt.Errorf("must supply a name")
+		}
+		if len(servers) == 0 {
+			return nil, fmt.Errorf("no nameservers configured")
+		}
+
+		name := names[0]
+		buf := bytes.NewBufferString(fmt.Sprintf(";; QUESTION SECTION:\n;%s.\t\tIN\tA\n\n", name))
+
+		var lastErr error
+		for _, server := range servers {
+			queryTime := time.Duration(0)
+			if _, hops, err := state.routeTo(server); err == nil {
+				queryTime = roundTrip(hops)
+			}
+
+			ip, err := state.queryNameserver(server, name)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			buf.WriteString(fmt.Sprintf(";; ANSWER SECTION:\n%s.\t300\tIN\tA\t%s\n\n", name, ip))
+			buf.WriteString(fmt.Sprintf(";; Query time: %s\n;; SERVER: %s", formatLatency(queryTime), server))
+			return buf.Bytes(), nil
+		}
+
+		buf.WriteString(fmt.Sprintf(";; %v", lastErr))
+		return buf.Bytes(), nil
+	},
+}
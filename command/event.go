@@ -3,8 +3,10 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/atotto/clipboard"
+	"github.com/ckiely91/shellsim/clock"
 	"github.com/ckiely91/shellsim/screen"
 	termbox "github.com/nsf/termbox-go"
 )
@@ -24,11 +26,15 @@ const (
 	EventTypeEnter
 	EventTypeTab
 	EventTypeChar
+	EventTypePage
+	EventTypeCallback
 )
 
 type Event struct {
 	Type EventType
 	Text string
+	// Fn is run on the event loop for EventTypeCallback events
+	Fn func()
 }
 
 func EventLoop(state *State, screen *screen.Screen) {
@@ -76,29 +82,43 @@ mainLoop:
 			break
 		}
 
+		if evt.Type == EventTypeCallback {
+			evt.Fn()
+			screen.CurPath = state.Prompt()
+			screen.SidePane = state.mapPane()
+			screen.StatusLine = state.StatusLine()
+			screen.Redraw()
+			continue
+		}
+
+		if screen.Pager != nil {
+			pagerEvent(evt, screen)
+			continue
+		}
+
 		switch evt.Type {
 		case EventTypeCommand:
 			state.CommandHistory.Append(evt.Text)
 			screen.AppendLines(false, termbox.ColorDefault, fmt.Sprintf("%v > %v", screen.CurPath, evt.Text))
-			inputCmd, args, err := readLine([]rune(evt.Text))
-			if err != nil {
+			output, err := state.Run(evt.Text)
+			if _, ok := err.(invalidCommandError); ok {
+				screen.AppendLines(false, termbox.ColorRed, err.Error())
+			} else if err != nil {
 				screen.AppendLines(false, termbox.ColorRed, fmt.Sprintf("error: %v", err))
-			} else {
-				if cmd, ok := state.Commands[inputCmd]; ok {
-					output, err := cmd.Execute(state, args...)
-					if err != nil {
-						screen.AppendLines(false, termbox.ColorRed, fmt.Sprintf("error: %v", err))
-					} else if output != nil {
-						screen.AppendLines(false, termbox.ColorWhite, strings.Split(string(output), "\n")...)
-					}
+			} else if output != nil {
+				lines := strings.Split(string(output), "\n")
+				if state.AutoPage && len(lines) >= screen.Height() {
+					screen.OpenPager(false, termbox.ColorWhite, lines...)
 				} else {
-					screen.AppendLines(false, termbox.ColorRed, fmt.Sprintf("invalid command: %s", inputCmd))
+					screen.AppendLines(false, termbox.ColorWhite, lines...)
 				}
 			}
 
 			screen.SetEditLine(false, []rune{})
 			// And set our current directory in case it changed
-			screen.CurPath = fmt.Sprintf("%v:%v", state.CurrentHost.Hostname, state.CurrentDir.FullPath())
+			screen.CurPath = state.Prompt()
+			screen.SidePane = state.mapPane()
+			screen.StatusLine = state.StatusLine()
 			screen.Redraw()
 		case EventTypeLog:
 			screen.AppendLines(true, termbox.ColorDefault, evt.Text)
@@ -126,7 +146,34 @@ mainLoop:
 			go sendEvent(state.EventChan, EventTypeCommand, string(screen.EditLine))
 		case EventTypeChar:
 			screen.AppendAtCursor(true, []rune(evt.Text)...)
+		case EventTypePage:
+			screen.OpenPager(true, termbox.ColorWhite, strings.Split(evt.Text, "\n")...)
+		}
+	}
+}
+
+// pagerEvent handles events while the pager has taken over the screen. Key
+// presses drive the pager; log lines still land in the scrollback.
+func pagerEvent(evt *Event, screen *screen.Screen) {
+	switch evt.Type {
+	case EventTypeLog:
+		screen.AppendLines(false, termbox.ColorDefault, evt.Text)
+	case EventTypeExit:
+		screen.ClosePager(true)
+	case EventTypeArrowUp:
+		screen.ScrollPager(true, -1)
+	case EventTypeArrowDown:
+		screen.ScrollPager(true, 1)
+	case EventTypeBackspace:
+		screen.PagerBackspace(true)
+	case EventTypeEnter:
+		screen.PagerEnter(true)
+	case EventTypeChar:
+		for _, c := range evt.Text {
+			screen.PagerKey(true, c)
 		}
+	case EventTypePage:
+		screen.OpenPager(true, termbox.ColorWhite, strings.Split(evt.Text, "\n")...)
 	}
 }
 
@@ -134,6 +181,43 @@ func sendEvent(ch chan *Event, evt EventType, text string) {
 	ch <- &Event{Type: evt, Text: text}
 }
 
+// callback runs fn on the event loop, so it can safely change the state
+// between commands. A fake clock's callbacks already run on whoever calls
+// Advance, which needn't be running an event loop, so fn runs there directly.
+func (s *State) callback(fn func()) {
+	if s.Clock.Fake() {
+		fn()
+		return
+	}
+	s.EventChan <- &Event{Type: EventTypeCallback, Fn: fn}
+}
+
+// after runs fn on the event loop once d of simulated time has passed.
+func (s *State) after(d time.Duration, fn func()) *clock.Timer {
+	return s.at(s.Clock.Now().Add(d), fn)
+}
+
+// at runs fn on the event loop once the simulated time reaches when
+func (s *State) at(when time.Time, fn func()) *clock.Timer {
+	return s.Clock.At(when, func() {
+		s.callback(fn)
+	})
+}
+
+// scheduleEvent sends an event once d of simulated time has passed. Events
+// scheduled for the same moment arrive in the order they were scheduled,
+// except on a fake clock, where nothing may be reading them and they're sent
+// without blocking Advance.
+func (s *State) scheduleEvent(d time.Duration, evt EventType, text string) *clock.Timer {
+	return s.Clock.AfterFunc(d, func() {
+		if s.Clock.Fake() {
+			go sendEvent(s.EventChan, evt, text)
+			return
+		}
+		sendEvent(s.EventChan, evt, text)
+	})
+}
+
 func readLine(line []rune) (cmd string, args []string, err error) {
 	curArg := ""
 	quoteOpen := false
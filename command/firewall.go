@@ -0,0 +1,187 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+type FirewallAction uint8
+
+const (
+	FirewallAllow FirewallAction = iota
+	FirewallDeny
+)
+
+func (a FirewallAction) String() string {
+	if a == FirewallDeny {
+		return "deny"
+	}
+	return "allow"
+}
+
+func parseFirewallAction(s string) (FirewallAction, error) {
+	switch s {
+	case "allow":
+		return FirewallAllow, nil
+	case "deny":
+		return FirewallDeny, nil
+	}
+	return FirewallAllow, fmt.Errorf("action must be allow or deny")
+}
+
+// FirewallRule matches traffic from a source to a port. The source may be a
+// hostname, an IP, a CIDR range or * for any host, and a port of 0 matches
+// any port.
+type FirewallRule struct {
+	Action FirewallAction
+	Source string
+	Port   int
+}
+
+func (r *FirewallRule) matches(source *Host, port int) bool {
+	return r.matchesSource(source) && (r.Port == 0 || r.Port == port)
+}
+
+func (r *FirewallRule) matchesSource(source *Host) bool {
+	return hostMatches(r.Source, source)
+}
+
+// hostMatches reports whether a host fits a hostname, an IP, a CIDR range or
+// * for any host
+func hostMatches(spec string, host *Host) bool {
+	if spec == "*" || spec == host.Hostname {
+		return true
+	}
+
+	if ip := net.ParseIP(spec); ip != nil {
+		return host.HasIP(ip)
+	}
+
+	if _, cidr, err := net.ParseCIDR(spec); err == nil {
+		for _, iface := range host.Interfaces {
+			if cidr.Contains(iface.IP) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (r *FirewallRule) String() string {
+	port := "any"
+	if r.Port != 0 {
+		port = strconv.Itoa(r.Port)
+	}
+	return fmt.Sprintf("%-5s from %s to port %s", r.Action, r.Source, port)
+}
+
+// Firewall filters incoming traffic to a host. Rules are checked in order
+// and the first match wins; traffic matching no rule gets the default policy.
+type Firewall struct {
+	DefaultPolicy FirewallAction
+	Rules         []*FirewallRule
+}
+
+// Allows reports whether traffic from the source host to port is let
+// through. A port of 0 is used for probes that don't target a port, such as
+// scan, and only matches rules covering any port.
+func (f *Firewall) Allows(source *Host, port int) bool {
+	for _, rule := range f.Rules {
+		if rule.matches(source, port) {
+			return rule.Action == FirewallAllow
+		}
+	}
+	return f.DefaultPolicy == FirewallAllow
+}
+
+func (f *Firewall) AddRule(action FirewallAction, source string, port int) {
+	f.Rules = append(f.Rules, &FirewallRule{Action: action, Source: source, Port: port})
+}
+
+// checkFirewall returns an error if traffic from the current host to the
+// given port on host is blocked.
+func (s *State) checkFirewall(host *Host, port int) error {
+	if host == s.CurrentHost || host.Firewall.Allows(s.CurrentHost, port) {
+		return nil
+	}
+	return fmt.Errorf("connection to %s port %d blocked by firewall", host.Hostname, port)
+}
+
+var FirewallCommand = &Command{
+	ShortHelp: "Inspect or modify the current host's firewall",
+	LongHelp: `Inspect or modify the current host's firewall. Rules are checked in order and the first
+matching rule decides whether traffic is let through. A source may be a hostname, IP or
+CIDR range, * matches any host, and leaving out the port matches every port. Modifying the firewall requires root.
+Usage: firewall
+       firewall allow|deny [source host] [port]
+       firewall delete [rule number]
+       firewall policy allow|deny`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeServer},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		firewall := state.CurrentHost.Firewall
+
+		if len(args) == 0 {
+			buf := bytes.NewBufferString(fmt.Sprintf("Default policy: %s\n", firewall.DefaultPolicy))
+			if len(firewall.Rules) == 0 {
+				buf.WriteString("No rules.")
+			}
+			for i, rule := range firewall.Rules {
+				buf.WriteString(fmt.Sprintf("  %d. %s\n", i+1, rule))
+			}
+			return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+		}
+
+		if state.CurrentUser == nil || state.CurrentUser.Name != "root" {
+			return nil, fmt.Errorf("permission denied: must be root to modify the firewall")
+		}
+
+		switch args[0] {
+		case "allow", "deny":
+			if len(args) < 2 || len(args) > 3 {
+				return nil, fmt.Errorf("must supply a source host and optionally a port")
+			}
+
+			action, _ := parseFirewallAction(args[0])
+			port := 0
+			if len(args) == 3 {
+				var err error
+				if port, err = strconv.Atoi(args[2]); err != nil || port <= 0 {
+					return nil, fmt.Errorf("invalid port %s", args[2])
+				}
+			}
+
+			firewall.AddRule(action, args[1], port)
+			return []byte(fmt.Sprintf("added rule %d", len(firewall.Rules))), nil
+		case "delete":
+			if len(args) != 2 {
+				return nil, fmt.Errorf("must supply a rule number")
+			}
+
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 || n > len(firewall.Rules) {
+				return nil, fmt.Errorf("no rule %s", args[1])
+			}
+
+			firewall.Rules = append(firewall.Rules[:n-1], firewall.Rules[n:]...)
+			return []byte(fmt.Sprintf("deleted rule %d", n)), nil
+		case "policy":
+			if len(args) != 2 {
+				return nil, fmt.Errorf("must supply allow or deny")
+			}
+
+			action, err := parseFirewallAction(args[1])
+			if err != nil {
+				return nil, err
+			}
+
+			firewall.DefaultPolicy = action
+			return []byte(fmt.Sprintf("default policy set to %s", action)), nil
+		}
+
+		return nil, fmt.Errorf("unknown firewall command: %s", strings.Join(args, " "))
+	},
+}
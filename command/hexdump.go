@@ -0,0 +1,159 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// printable reports whether b is a printable ASCII character
+func printable(b byte) bool {
+	return b >= 0x20 && b < 0x7f
+}
+
+// printableString shows the printable bytes of data, with dots for the rest
+func printableString(data []byte) string {
+	shown := make([]byte, len(data))
+	for i, b := range data {
+		shown[i] = '.'
+		if printable(b) {
+			shown[i] = b
+		}
+	}
+	return string(shown)
+}
+
+// hexdump formats data canonically: an offset, sixteen bytes in hex and
+// the same bytes as characters.
+func hexdump(data []byte) []byte {
+	buf := &bytes.Buffer{}
+	for offset := 0; offset < len(data); offset += 16 {
+		end := offset + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		line := data[offset:end]
+		buf.WriteString(fmt.Sprintf("%08x ", offset))
+		for i := 0; i < 16; i++ {
+			if i == 8 {
+				buf.WriteByte(' ')
+			}
+			if i < len(line) {
+				buf.WriteString(fmt.Sprintf(" %02x", line[i]))
+			} else {
+				buf.WriteString("   ")
+			}
+		}
+		buf.WriteString(fmt.Sprintf("  |%s|\n", printableString(line)))
+	}
+	buf.WriteString(fmt.Sprintf("%08x", len(data)))
+	return buf.Bytes()
+}
+
+// xxd formats data as xxd does, with the bytes in pairs
+func xxd(data []byte) []byte {
+	buf := &bytes.Buffer{}
+	for offset := 0; offset < len(data); offset += 16 {
+		end := offset + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		line := data[offset:end]
+		buf.WriteString(fmt.Sprintf("%08x:", offset))
+		for i := 0; i < 16; i++ {
+			if i%2 == 0 {
+				buf.WriteByte(' ')
+			}
+			if i < len(line) {
+				buf.WriteString(fmt.Sprintf("%02x", line[i]))
+			} else {
+				buf.WriteString("  ")
+			}
+		}
+		buf.WriteString(fmt.Sprintf("  %s\n", printableString(line)))
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// printableRuns finds the runs of at least min printable characters in data
+func printableRuns(data []byte, min int) []string {
+	runs := []string{}
+	start := -1
+	for i := 0; i <= len(data); i++ {
+		if i < len(data) && printable(data[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= min {
+			runs = append(runs, string(data[start:i]))
+		}
+		start = -1
+	}
+	return runs
+}
+
+var HexdumpCommand = &Command{
+	ShortHelp: "Show the bytes of a file in hex",
+	LongHelp: `Show the bytes of a file in hex, sixteen to a line, alongside any printable characters.
+Usage: hexdump [path to file]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a file path")
+		}
+
+		_, data, err := fileData(state, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return hexdump(data), nil
+	},
+}
+
+var XXDCommand = &Command{
+	ShortHelp: "Show the bytes of a file in hex, xxd style",
+	LongHelp: `Show the bytes of a file in hex, in pairs, sixteen to a line, alongside any printable characters.
+Usage: xxd [path to file]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a file path")
+		}
+
+		_, data, err := fileData(state, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return xxd(data), nil
+	},
+}
+
+var StringsCommand = &Command{
+	ShortHelp: "Find readable text in a file",
+	LongHelp: `Print each run of printable characters in a file at least four long, or n long with -n.
+Handy for finding text hidden in binary files.
+Usage: strings [-n min] [path to file]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		minLength := 4
+		if len(args) == 3 && args[0] == "-n" {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid minimum length %s", args[1])
+			}
+			minLength, args = n, args[2:]
+		}
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a file path")
+		}
+
+		_, data, err := fileData(state, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.Join(printableRuns(data, minLength), "\n")), nil
+	},
+}
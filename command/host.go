@@ -1,20 +1,130 @@
 package command
 
-import "github.com/ckiely91/shellsim/fs"
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
 
 type Host struct {
-	Hostname       string
-	RootDir        *fs.Directory
-	ConnectedHosts map[string]*Host
+	Hostname   string
+	RootDir    *fs.Directory
+	Users      map[string]*User
+	Services   map[int]*Service
+	Firewall   *Firewall
+	Interfaces []*Interface
+	Routes     []*Route
+	Forwarding bool
+	DomainName string
+	DNSRecords map[string]net.IP
+	// IDSLevel is how closely the host watches for intruders. Zero means
+	// connections to it are never traced.
+	IDSLevel         int
+	Processes        []*Process
+	PasswordStrength PasswordStrength
+	// Commands are built in to the host's shell. Nil means the standard set.
+	Commands map[string]*Command
+	// Restricted shells only have their built in commands and can't run
+	// programs
+	Restricted bool
+	// Capacity is how many bytes of files the host can store. Zero means
+	// there is no limit.
+	Capacity int64
+	// Devices are the drives attached to the host that can be mounted, by
+	// device name
+	Devices map[string]*Volume
+
+	lastPID int
+}
+
+// NewHost creates a host with an empty filesystem and a root account
+func NewHost(hostname string, created time.Time) *Host {
+	host := &Host{
+		Hostname:   hostname,
+		RootDir:    fs.NewDirectory(nil, "", created),
+		Users:      map[string]*User{},
+		Services:   map[int]*Service{},
+		Firewall:   &Firewall{DefaultPolicy: FirewallAllow},
+		DNSRecords: map[string]net.IP{},
+		Devices:    map[string]*Volume{},
+	}
+	// The root user's home is a fixed, valid path, so this can't fail
+	if _, err := host.AddUser("root", "/root", created); err != nil {
+		panic(err)
+	}
+	host.StartProcess("root", "init", 0, created)
+
+	return host
 }
 
-func NewHost(hostname string) *Host {
-	return &Host{
-		Hostname: hostname,
-		RootDir: &fs.Directory{
-			Parent: nil,
-			Files:  map[string]fs.File{},
-		},
-		ConnectedHosts: map[string]*Host{},
+// WriteFile creates or replaces a text file at an absolute path on the host,
+// creating any missing directories.
+func (h *Host) WriteFile(path, contents string, now time.Time) error {
+	name := path[strings.LastIndex(path, "/")+1:]
+	return h.writeFile(path, fs.NewText(name, []byte(contents), now), now)
+}
+
+// WriteEncryptedFile is like WriteFile, but the file can only be read once
+// it has been decrypted with key.
+func (h *Host) WriteEncryptedFile(path, contents, key string, now time.Time) error {
+	name := path[strings.LastIndex(path, "/")+1:]
+	return h.writeFile(path, fs.NewEncrypted(name, []byte(contents), key, now), now)
+}
+
+// appendFile adds to the end of a text file at an absolute path on the host,
+// creating it if it doesn't exist. If something other than a text file is in
+// the way, or the disk is full, the contents are quietly dropped, as logs are.
+func (h *Host) appendFile(path, contents string, now time.Time) {
+	if h.reserve(int64(len(contents))) != nil {
+		return
 	}
+	if file, ok := fs.FindFileRelative(h.RootDir, h.RootDir, path).(*fs.Text); ok {
+		file.Contents = append(file.Contents, contents...)
+		file.Modified = now
+		return
+	}
+
+	h.WriteFile(path, contents, now)
+}
+
+// findHost looks up a host by hostname, DNS name or IP and checks that the current
+// host can reach it. The current host can always reach itself.
+func (s *State) findHost(address string) (*Host, error) {
+	host, _, err := s.routeTo(address)
+	return host, err
+}
+
+// routeTo finds a host by hostname, DNS name or IP along with the path to it
+// from the current host.
+func (s *State) routeTo(address string) (*Host, []*Hop, error) {
+	address, err := s.resolveAddress(address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	host := s.Network.HostByAddress(address)
+	if host == nil {
+		return nil, nil, fmt.Errorf("host %s not found", address)
+	}
+	if host == s.CurrentHost {
+		return host, []*Hop{}, nil
+	}
+
+	dst := net.ParseIP(address)
+	if dst == nil || !host.HasIP(dst) {
+		dst = host.PrimaryIP()
+	}
+	if dst == nil {
+		return nil, nil, fmt.Errorf("host %s has no network address", address)
+	}
+
+	hops, err := s.Network.Route(s.CurrentHost, dst)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot reach %s: %v", address, err)
+	}
+
+	return host, hops, nil
 }
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// alwaysAvailable are kept by every host, however restricted, so players can
+// always find their way around and back out.
+var alwaysAvailable = []string{"help", "exit"}
+
+// DisableCommands removes commands from the host's shell
+func (h *Host) DisableCommands(names ...string) {
+	commands := h.shellCommands()
+	for _, name := range names {
+		delete(commands, name)
+	}
+}
+
+// RestrictCommands gives the host a restricted shell that only has the named
+// commands, plus help and exit. Restricted shells never run programs.
+func (h *Host) RestrictCommands(names ...string) {
+	standard := standardCommands()
+	h.Commands = map[string]*Command{}
+	h.Restricted = true
+	for _, name := range append(names, alwaysAvailable...) {
+		if cmd, ok := standard[name]; ok {
+			h.Commands[name] = cmd
+		}
+	}
+}
+
+// SetCommand adds a command to the host's shell, replacing any built in
+// command of the same name.
+func (h *Host) SetCommand(name string, cmd *Command) {
+	h.shellCommands()[name] = cmd
+}
+
+// shellCommands returns the host's own command set, copying the standard set
+// the first time it is changed.
+func (h *Host) shellCommands() map[string]*Command {
+	if h.Commands == nil {
+		h.Commands = standardCommands()
+	}
+	return h.Commands
+}
+
+// builtinCommands returns the commands built in to the current host's shell
+func (s *State) builtinCommands() map[string]*Command {
+	if s.CurrentHost.Commands != nil {
+		return s.CurrentHost.Commands
+	}
+	return s.Commands
+}
+
+var ShowCommand = &Command{
+	ShortHelp: "Show the current host's configuration",
+	LongHelp: `Show the current host's interfaces, routing table, firewall rules or services. Routers
+with restricted shells offer this in place of the usual commands.
+Usage: show interfaces|routes|firewall|services`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply interfaces, routes, firewall or services")
+		}
+
+		host := state.CurrentHost
+		switch args[0] {
+		case "interfaces":
+			return formatInterfaces(host), nil
+		case "routes":
+			return formatRoutes(host), nil
+		case "firewall":
+			return FirewallCommand.Execute(state)
+		case "services":
+			if len(host.Services) == 0 {
+				return []byte("No services."), nil
+			}
+
+			buf := &bytes.Buffer{}
+			for _, service := range host.SortedServices() {
+				buf.WriteString(fmt.Sprintf("%5d  %-5s %s\n", service.Port, service.Type, service.Version))
+			}
+			return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+		}
+
+		return nil, fmt.Errorf("unknown show command: %s", args[0])
+	},
+}
@@ -0,0 +1,86 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+var LNCommand = &Command{
+	ShortHelp: "Create a link to a file",
+	LongHelp: `Create a link to a file. By default a hard link is created, which is another name for
+the same text file on the same disk. With -s a symbolic link is created instead, which points
+at a path and may refer to directories, other links, or files that do not exist yet.
+Usage: ln [-s] [target] [link name]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		flags, paths, err := parseFlags(args, "s")
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) != 2 {
+			return nil, fmt.Errorf("must supply a target and a link name")
+		}
+
+		target, linkPath := paths[0], paths[1]
+
+		dir, name, err := state.findNewFilePath(linkPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if flags['s'] {
+			if err := state.addFile(dir, fs.NewSymlink(name, target, state.Clock.Now())); err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+
+		foundFile := state.FindFile(target)
+		if foundFile == nil {
+			return nil, fmt.Errorf("file not found")
+		}
+
+		textFile, ok := foundFile.(*fs.Text)
+		if !ok {
+			return nil, fmt.Errorf("hard links can only be made to text files")
+		}
+
+		root := state.CurrentHost.RootDir
+		if root.MountOf(state.dirOf(target)) != root.MountOf(dir) {
+			return nil, fmt.Errorf("invalid cross-device link")
+		}
+
+		// The link shares the target's contents, so needs no space of its own
+		if err := state.checkWritable(dir); err != nil {
+			return nil, err
+		}
+		state.putFile(dir, textFile.HardLink(name))
+
+		return nil, nil
+	},
+}
+
+var ReadlinkCommand = &Command{
+	ShortHelp: "Print the target of a symbolic link",
+	LongHelp: `Print the target of a symbolic link.
+Usage: readlink [path to link]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a path to a link")
+		}
+
+		foundFile := state.FindFileNoFollow(args[0])
+		if foundFile == nil {
+			return nil, fmt.Errorf("file not found")
+		}
+
+		link, ok := foundFile.(*fs.Symlink)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a symbolic link", args[0])
+		}
+
+		return []byte(link.Target), nil
+	},
+}
@@ -0,0 +1,232 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+// mailSpool is where each user's messages are kept on the local host. Mail is
+// stored as ordinary files so it is saved along with the rest of the world.
+const mailSpool = "/var/mail"
+
+// Message is a piece of mail the world sends the player. Delay holds delivery
+// back for that many seconds after it is triggered.
+type Message struct {
+	From    string `json:"from"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	Delay   int    `json:"delay,omitempty"`
+}
+
+// mailItem is a message as stored in a mailbox file
+type mailItem struct {
+	file    *fs.Text
+	from    string
+	subject string
+	date    string
+	unread  bool
+	body    string
+}
+
+func (s *State) mailDir() string {
+	return fmt.Sprintf("%s/%s", mailSpool, s.LocalUser.Name)
+}
+
+// mailFolder finds one of the local user's mail folders, if it exists yet
+func (s *State) mailFolder(folder string) (*fs.Directory, bool) {
+	dir, ok := fs.FindFileRelative(s.LocalHost.RootDir, s.LocalHost.RootDir, s.mailDir()+folder).(*fs.Directory)
+	return dir, ok
+}
+
+func formatMail(from, subject, status string, date time.Time, body string) string {
+	return fmt.Sprintf("From: %s\nSubject: %s\nDate: %s\nStatus: %s\n\n%s\n",
+		from, subject, date.Format(time.RFC1123Z), status, strings.TrimSuffix(body, "\n"))
+}
+
+func parseMail(file *fs.Text) *mailItem {
+	item := &mailItem{file: file}
+	headers, body, _ := strings.Cut(string(file.Contents), "\n\n")
+	item.body = strings.TrimSuffix(body, "\n")
+	for _, line := range strings.Split(headers, "\n") {
+		key, value, _ := strings.Cut(line, ": ")
+		switch key {
+		case "From":
+			item.from = value
+		case "Subject":
+			item.subject = value
+		case "Date":
+			item.date = value
+		case "Status":
+			item.unread = value == "N"
+		}
+	}
+	return item
+}
+
+// mailbox returns the messages in one of the local user's mail folders,
+// oldest first.
+func (s *State) mailbox(folder string) []*mailItem {
+	dir, ok := s.mailFolder(folder)
+	if !ok {
+		return nil
+	}
+
+	names := []string{}
+	for name, file := range dir.Files {
+		if file.Type() == fs.FileTypeText {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	items := []*mailItem{}
+	for _, name := range names {
+		items = append(items, parseMail(dir.Files[name].(*fs.Text)))
+	}
+	return items
+}
+
+func (s *State) unreadMail() int {
+	unread := 0
+	for _, item := range s.mailbox("") {
+		if item.unread {
+			unread++
+		}
+	}
+	return unread
+}
+
+// nextMailNumber is one past the highest numbered message in a folder, so
+// new mail sorts last and never lands on a message that is still there
+func (s *State) nextMailNumber(folder string) int {
+	next := 1
+	dir, ok := s.mailFolder(folder)
+	if !ok {
+		return next
+	}
+
+	for name := range dir.Files {
+		var n int
+		if _, err := fmt.Sscanf(name, "%d.msg", &n); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	return next
+}
+
+// storeMail writes a message into one of the local user's mail folders
+func (s *State) storeMail(folder, contents string) {
+	now := s.Clock.Now()
+	path := fmt.Sprintf("%s%s/%04d.msg", s.mailDir(), folder, s.nextMailNumber(folder))
+	if err := s.LocalHost.WriteFile(path, contents, now); err != nil {
+		s.Logf("error delivering mail: %v", err)
+	}
+}
+
+// deliverMail puts a message in the player's inbox straight away and returns
+// the line announcing it.
+func (s *State) deliverMail(msg *Message) string {
+	s.storeMail("", formatMail(msg.From, msg.Subject, "N", s.Clock.Now(), msg.Body))
+	return fmt.Sprintf("New mail from %s: %s", msg.From, msg.Subject)
+}
+
+// sendMail delivers a message now, or after its delay. It returns the
+// announcement for immediate deliveries and an empty string otherwise.
+func (s *State) sendMail(msg *Message) string {
+	if msg.Delay <= 0 {
+		return s.deliverMail(msg)
+	}
+
+	s.after(time.Duration(msg.Delay)*time.Second, func() {
+		s.Logf("%s", s.deliverMail(msg))
+	})
+	return ""
+}
+
+// mailItemArg finds the message numbered by a command argument
+func (s *State) mailItemArg(arg string) (*mailItem, error) {
+	inbox := s.mailbox("")
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(inbox) {
+		return nil, fmt.Errorf("no message %s", arg)
+	}
+	return inbox[n-1], nil
+}
+
+// repliedTo reports whether the player has replied to a message with the
+// given subject
+func (s *State) repliedTo(subject string) bool {
+	for _, item := range s.mailbox("/sent") {
+		if strings.EqualFold(item.subject, "Re: "+subject) {
+			return true
+		}
+	}
+	return false
+}
+
+var MailCommand = &Command{
+	ShortHelp: "Read and reply to your mail",
+	LongHelp: `List the messages in your inbox, read one, or reply to one. Mail always comes to the
+user on your own machine, wherever you are connected.
+Usage: mail [read n|reply n text]`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) == 0 {
+			inbox := state.mailbox("")
+			if len(inbox) == 0 {
+				return []byte("No mail."), nil
+			}
+
+			buf := &bytes.Buffer{}
+			for i, item := range inbox {
+				status := " "
+				if item.unread {
+					status = "N"
+				}
+				buf.WriteString(fmt.Sprintf("%s %3d  %-20s %s\n", status, i+1, item.from, item.subject))
+			}
+			return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+		}
+
+		switch args[0] {
+		case "read":
+			if len(args) != 2 {
+				return nil, fmt.Errorf("must supply a message number")
+			}
+			item, err := state.mailItemArg(args[1])
+			if err != nil {
+				return nil, err
+			}
+
+			if item.unread {
+				item.file.Contents = bytes.Replace(item.file.Contents, []byte("\nStatus: N\n"), []byte("\nStatus: R\n"), 1)
+				item.file.Modified = state.Clock.Now()
+			}
+
+			return []byte(fmt.Sprintf("From: %s\nSubject: %s\nDate: %s\n\n%s", item.from, item.subject, item.date, item.body)), nil
+		case "reply":
+			if len(args) < 3 {
+				return nil, fmt.Errorf("must supply a message number and some text")
+			}
+			item, err := state.mailItemArg(args[1])
+			if err != nil {
+				return nil, err
+			}
+
+			subject := item.subject
+			if !strings.HasPrefix(strings.ToLower(subject), "re: ") {
+				subject = "Re: " + subject
+			}
+			state.storeMail("/sent", formatMail(state.LocalUser.Name, subject, "R", state.Clock.Now(), strings.Join(args[2:], " ")))
+
+			return []byte(fmt.Sprintf("replied to %s", item.from)), nil
+		}
+
+		return nil, fmt.Errorf("unknown mail command: %s", args[0])
+	},
+}
@@ -0,0 +1,333 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+// localHostName can be used as the host of a condition to mean the player's
+// own machine, whatever it is called in the world.
+const localHostName = "localhost"
+
+type ConditionType string
+
+const (
+	// ConditionFileExists is met when a file exists at Path on Host
+	ConditionFileExists ConditionType = "file_exists"
+	// ConditionFileContains is met when the text file at Path on Host
+	// contains Text
+	ConditionFileContains ConditionType = "file_contains"
+	// ConditionFileNotContains is met when there is no text file at Path on
+	// Host containing Text, such as once a log has been cleaned up
+	ConditionFileNotContains ConditionType = "file_not_contains"
+	// ConditionConnectedTo is met when the player is connected to Host
+	ConditionConnectedTo ConditionType = "connected_to"
+	// ConditionMailReplied is met when the player has replied to the mail
+	// whose subject is Text
+	ConditionMailReplied ConditionType = "mail_replied"
+)
+
+// Objective is one step of a mission, completed once its condition has been
+// met. Objectives stay complete even if the condition later stops holding.
+// Cleanup objectives are only checked once all the others are complete.
+type Objective struct {
+	Description string        `json:"description"`
+	Type        ConditionType `json:"type"`
+	Host        string        `json:"host"`
+	Path        string        `json:"path,omitempty"`
+	Text        string        `json:"text,omitempty"`
+
+	Complete bool `json:"-"`
+}
+
+type Mission struct {
+	ID                string       `json:"id"`
+	Title             string       `json:"title"`
+	Description       string       `json:"description"`
+	Requires          string       `json:"requires,omitempty"`
+	Objectives        []*Objective `json:"objectives"`
+	CompletionMessage string       `json:"completion_message,omitempty"`
+	// Briefing is mailed to the player when the mission becomes available
+	// and Debrief when it is complete
+	Briefing *Message `json:"briefing,omitempty"`
+	Debrief  *Message `json:"debrief,omitempty"`
+	// Files are put in place when the mission becomes available
+	Files []*WorldFile `json:"files,omitempty"`
+	// TimeLimit is how many seconds the player has to finish the mission
+	// once it becomes available. Zero means there is no limit.
+	TimeLimit int `json:"time_limit,omitempty"`
+
+	Complete bool `json:"-"`
+	Failed   bool `json:"-"`
+	briefed  bool
+	deadline time.Time
+}
+
+// LoadMissions reads a JSON array of missions
+func LoadMissions(r io.Reader) ([]*Mission, error) {
+	missions := []*Mission{}
+	if err := json.NewDecoder(r).Decode(&missions); err != nil {
+		return nil, fmt.Errorf("invalid missions file: %v", err)
+	}
+
+	ids := map[string]bool{}
+	for _, m := range missions {
+		if m.ID == "" || ids[m.ID] {
+			return nil, fmt.Errorf("missions must have unique ids, got %q", m.ID)
+		}
+		ids[m.ID] = true
+
+		for _, f := range m.Files {
+			if _, err := f.file(time.Time{}); err != nil {
+				return nil, fmt.Errorf("mission %s: file %s: %v", m.ID, f.Path, err)
+			}
+		}
+
+		for _, o := range m.Objectives {
+			switch o.Type {
+			case ConditionFileExists, ConditionFileContains, ConditionFileNotContains, ConditionConnectedTo, ConditionMailReplied:
+			default:
+				return nil, fmt.Errorf("mission %s: unknown objective type %q", m.ID, o.Type)
+			}
+		}
+	}
+
+	for _, m := range missions {
+		if m.Requires != "" && !ids[m.Requires] {
+			return nil, fmt.Errorf("mission %s requires unknown mission %s", m.ID, m.Requires)
+		}
+	}
+
+	return missions, nil
+}
+
+// missionHost finds the host a condition refers to by hostname, IP or DNS
+// name. Conditions see the whole world, not just what the player can reach.
+func (s *State) missionHost(name string) *Host {
+	if name == localHostName {
+		return s.LocalHost
+	}
+
+	if host := s.Network.HostByAddress(name); host != nil {
+		return host
+	}
+
+	for _, host := range s.Network.Hosts {
+		if host.DomainName != "" && strings.EqualFold(host.DomainName, name) {
+			return host
+		}
+	}
+
+	return nil
+}
+
+func (o *Objective) met(s *State) bool {
+	if o.Type == ConditionMailReplied {
+		return s.repliedTo(o.Text)
+	}
+
+	host := s.missionHost(o.Host)
+	if host == nil {
+		return false
+	}
+
+	switch o.Type {
+	case ConditionConnectedTo:
+		return s.CurrentHost == host
+	case ConditionFileExists:
+		return fs.FindFileRelative(host.RootDir, host.RootDir, o.Path) != nil
+	case ConditionFileContains:
+		file, ok := fs.FindFileRelative(host.RootDir, host.RootDir, o.Path).(*fs.Text)
+		return ok && bytes.Contains(file.Contents, []byte(o.Text))
+	case ConditionFileNotContains:
+		file, ok := fs.FindFileRelative(host.RootDir, host.RootDir, o.Path).(*fs.Text)
+		return !ok || !bytes.Contains(file.Contents, []byte(o.Text))
+	}
+
+	return false
+}
+
+// cleanup reports whether the objective is about removing something, rather
+// than achieving something
+func (o *Objective) cleanup() bool {
+	return o.Type == ConditionFileNotContains
+}
+
+// missionByID returns the mission with the given id, or nil
+func (s *State) missionByID(id string) *Mission {
+	for _, m := range s.Missions {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+// available reports whether the mission has been unlocked
+func (m *Mission) available(s *State) bool {
+	if m.Requires == "" {
+		return true
+	}
+	required := s.missionByID(m.Requires)
+	return required != nil && required.Complete
+}
+
+// checkMissions evaluates every unlocked mission's objectives, announcing
+// any that have just been completed.
+func (s *State) checkMissions() {
+	if lines := s.updateMissions(); len(lines) > 0 {
+		s.logLines(lines...)
+	}
+}
+
+// updateMissions marks newly met objectives and missions complete, returning
+// a line announcing each.
+func (s *State) updateMissions() []string {
+	lines := []string{}
+	for _, m := range s.Missions {
+		if m.Complete || m.Failed || !m.available(s) {
+			continue
+		}
+
+		if !m.briefed {
+			m.briefed = true
+			for _, f := range m.Files {
+				if err := f.place(s); err != nil {
+					lines = append(lines, fmt.Sprintf("Mission %s: %v", m.ID, err))
+				}
+			}
+			if m.Briefing != nil {
+				lines = appendMailLine(lines, s.sendMail(m.Briefing))
+			}
+			if m.TimeLimit > 0 {
+				s.startMissionTimer(m)
+			}
+		}
+
+		complete := true
+		for _, o := range m.Objectives {
+			if !o.Complete && !o.cleanup() && o.met(s) {
+				o.Complete = true
+				lines = append(lines, fmt.Sprintf("Objective complete: %s", o.Description))
+			}
+			complete = complete && (o.Complete || o.cleanup())
+		}
+
+		// Cleanup objectives only count once everything else is done, so
+		// they can't be met before the player has left any tracks
+		for _, o := range m.Objectives {
+			if complete && !o.Complete && o.cleanup() && o.met(s) {
+				o.Complete = true
+				lines = append(lines, fmt.Sprintf("Objective complete: %s", o.Description))
+			}
+			complete = complete && o.Complete
+		}
+
+		if complete {
+			m.Complete = true
+			lines = append(lines, fmt.Sprintf("Mission complete: %s", m.Title))
+			if m.CompletionMessage != "" {
+				lines = append(lines, m.CompletionMessage)
+			}
+			if m.Debrief != nil {
+				lines = appendMailLine(lines, s.sendMail(m.Debrief))
+			}
+
+			// Finishing this one may unlock and even complete others
+			return append(lines, s.updateMissions()...)
+		}
+	}
+	return lines
+}
+
+// startMissionTimer fails the mission if it isn't finished in time
+func (s *State) startMissionTimer(m *Mission) {
+	limit := time.Duration(m.TimeLimit) * time.Second
+	m.deadline = s.Clock.Now().Add(limit)
+	s.after(limit, func() {
+		if m.Complete {
+			return
+		}
+		m.Failed = true
+		s.Logf("Mission failed: %s ran out of time", m.Title)
+	})
+}
+
+// appendMailLine adds a mail announcement unless delivery was delayed
+func appendMailLine(lines []string, line string) []string {
+	if line == "" {
+		return lines
+	}
+	return append(lines, line)
+}
+
+// StartMissions sets the missions to play and sends the briefings for any
+// that are available from the start.
+func (s *State) StartMissions(missions []*Mission) {
+	s.Missions = missions
+	s.checkMissions()
+}
+
+var MissionsCommand = &Command{
+	ShortHelp: "List missions and your progress",
+	LongHelp: `List missions and your progress through their objectives. Include a mission id to see its
+full description.
+Usage: missions [id]`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) > 1 {
+			return nil, fmt.Errorf("must supply zero or one arguments")
+		}
+
+		if len(args) == 1 {
+			m := state.missionByID(args[0])
+			if m == nil || !m.available(state) {
+				return nil, fmt.Errorf("no mission %s", args[0])
+			}
+
+			buf := bytes.NewBufferString(fmt.Sprintf("%s\n%s\n", m.Title, m.Description))
+			writeObjectives(buf, m)
+			return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+		}
+
+		buf := &bytes.Buffer{}
+		for _, m := range state.Missions {
+			if !m.available(state) {
+				continue
+			}
+
+			status := "in progress"
+			switch {
+			case m.Complete:
+				status = "complete"
+			case m.Failed:
+				status = "failed"
+			case m.TimeLimit > 0:
+				status += fmt.Sprintf(", %s left", m.deadline.Sub(state.Clock.Now()).Round(time.Second))
+			}
+			buf.WriteString(fmt.Sprintf("%s (%s) - %s\n", m.Title, m.ID, status))
+			writeObjectives(buf, m)
+		}
+
+		if buf.Len() == 0 {
+			return []byte("No missions."), nil
+		}
+
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+	},
+}
+
+func writeObjectives(buf *bytes.Buffer, m *Mission) {
+	for _, o := range m.Objectives {
+		check := " "
+		if o.Complete {
+			check = "x"
+		}
+		buf.WriteString(fmt.Sprintf("  [%s] %s\n", check, o.Description))
+	}
+}
@@ -0,0 +1,235 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+// exportsPath lists the directories a host shares over NFS, one per line as
+// the path followed by the clients allowed to mount it, e.g.
+// "/srv/ftp 10.0.0.0/8(rw) *(ro)". Clients are matched in order, and shares
+// are read-only unless rw is given.
+const exportsPath = "/etc/exports"
+
+// Volume is a drive that can be mounted on a host, such as a USB stick
+type Volume struct {
+	Device   string
+	Root     *fs.Directory
+	Capacity int64
+}
+
+// AddDevice attaches an empty drive to the host under the device name
+func (h *Host) AddDevice(device string, capacity int64, now time.Time) *Volume {
+	v := &Volume{Device: device, Root: fs.NewDirectory(nil, "", now), Capacity: capacity}
+	h.Devices[device] = v
+	return v
+}
+
+// mountUsage returns the capacity of a mounted disk and how much of it is
+// used. Shares take up space on the host they are shared from.
+func (s *State) mountUsage(m *fs.Mount) (int64, int64) {
+	if v, ok := s.CurrentHost.Devices[m.Device]; ok && v.Root == m.Root {
+		return v.Capacity, v.Root.DiskUsage()
+	}
+
+	hostname, _, _ := strings.Cut(m.Device, ":")
+	if host := s.Network.HostByAddress(hostname); host != nil {
+		return host.Capacity, host.Used()
+	}
+	return 0, m.Root.DiskUsage()
+}
+
+// mountedBeneath reports whether anything is mounted on dir or beneath it on
+// the current host
+func (s *State) mountedBeneath(dir *fs.Directory) bool {
+	for _, m := range s.CurrentHost.RootDir.Mounts {
+		if m.Root == dir {
+			return true
+		}
+		for current := m.Point; current != nil; current = s.parentDir(current) {
+			if current == dir {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exported reports whether the host shares path over NFS with client, and
+// if so whether the client may only read it
+func exported(host *Host, path string, client *Host) (readOnly bool, ok bool) {
+	for _, line := range readConfigFile(host, exportsPath) {
+		fields := strings.Fields(line)
+		if strings.TrimSuffix(fields[0], "/") != strings.TrimSuffix(path, "/") {
+			continue
+		}
+
+		// Without any clients, the path is shared read-only with everyone
+		if len(fields) == 1 {
+			return true, true
+		}
+		for _, field := range fields[1:] {
+			spec, options, _ := strings.Cut(strings.TrimSuffix(field, ")"), "(")
+			if !hostMatches(spec, client) {
+				continue
+			}
+			for _, option := range strings.Split(options, ",") {
+				if option == "rw" {
+					return false, true
+				}
+			}
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// checkWritable fails if dir is on a share mounted read-only
+func (s *State) checkWritable(dir *fs.Directory) error {
+	if m := s.CurrentHost.RootDir.MountOf(dir); m != nil && m.ReadOnly {
+		return fmt.Errorf("read-only file system")
+	}
+	return nil
+}
+
+// findShare finds the directory another host shares at path, checking that
+// the current host can reach its NFS service and is allowed to mount it. It
+// also reports whether the share is read-only.
+func (s *State) findShare(address, path string) (*Host, *fs.Directory, bool, error) {
+	host, _, err := s.routeTo(address)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	nfs := host.ServiceOfType(ServiceTypeNFS)
+	if nfs == nil {
+		return nil, nil, false, fmt.Errorf("connection to %s refused: no nfs service running", host.Hostname)
+	}
+	if err := s.checkFirewall(host, nfs.Port); err != nil {
+		return nil, nil, false, err
+	}
+
+	dir, isDir := fs.FindFileRelative(host.RootDir, host.RootDir, path).(*fs.Directory)
+	readOnly, ok := exported(host, path, s.CurrentHost)
+	if !isDir || !ok {
+		return nil, nil, false, fmt.Errorf("access denied by %s while mounting %s", host.Hostname, path)
+	}
+	return host, dir, readOnly, nil
+}
+
+var MountCommand = &Command{
+	ShortHelp: "Mount a drive or network share, or list mounts",
+	LongHelp: `Mount a drive attached to the current host, such as /dev/sdb1, or a directory another host
+shares over NFS, written as host:/path, on a directory. Its files then appear in that directory.
+Hosts list the directories they share in /etc/exports, along with the clients that may mount
+each and whether they may write to it. Without arguments, lists what is mounted and the drives
+that could be. Only root can mount.
+Usage: mount [device|host:/path] [directory]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		host := state.CurrentHost
+		if len(args) == 0 {
+			buf := bytes.NewBufferString(fmt.Sprintf("%s on /\n", rootDevice))
+			for _, m := range host.RootDir.Mounts {
+				mode := "rw"
+				if m.ReadOnly {
+					mode = "ro"
+				}
+				buf.WriteString(fmt.Sprintf("%s on %s (%s)\n", m.Device, state.dirPath(m.Root), mode))
+			}
+			for device, v := range host.Devices {
+				if host.RootDir.MountOfRoot(v.Root) == nil {
+					buf.WriteString(fmt.Sprintf("%s not mounted\n", device))
+				}
+			}
+			return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+		}
+
+		if len(args) != 2 {
+			return nil, fmt.Errorf("must supply a device or share and a directory")
+		}
+		if state.CurrentUser.Name != "root" {
+			return nil, fmt.Errorf("only root can mount")
+		}
+
+		point, ok := state.FindFile(args[1]).(*fs.Directory)
+		if !ok {
+			return nil, fmt.Errorf("mount point %s is not a directory", args[1])
+		}
+
+		device := args[0]
+		var root *fs.Directory
+		readOnly := false
+		if v, ok := host.Devices[device]; ok {
+			root = v.Root
+		} else if address, path, ok := strings.Cut(device, ":"); ok {
+			share, dir, ro, err := state.findShare(address, path)
+			if err != nil {
+				return nil, err
+			}
+			if share == host {
+				return nil, fmt.Errorf("cannot mount a share from the same host")
+			}
+			device, root, readOnly = fmt.Sprintf("%s:%s", share.Hostname, path), dir, ro
+		} else {
+			return nil, fmt.Errorf("no such device %s", device)
+		}
+
+		m, err := host.RootDir.Mount(device, point, root)
+		if err != nil {
+			return nil, err
+		}
+		m.ReadOnly = readOnly
+
+		if readOnly {
+			return []byte(fmt.Sprintf("mounted %s on %s (read-only)", device, state.dirPath(root))), nil
+		}
+		return []byte(fmt.Sprintf("mounted %s on %s", device, state.dirPath(root))), nil
+	},
+}
+
+var UmountCommand = &Command{
+	ShortHelp: "Unmount a drive or network share",
+	LongHelp: `Unmount whatever is mounted on a directory, or a device wherever it is mounted. It can't be
+unmounted while you are inside it. Only root can unmount.
+Usage: umount [directory|device]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a directory or device")
+		}
+		if state.CurrentUser.Name != "root" {
+			return nil, fmt.Errorf("only root can unmount")
+		}
+
+		root := state.CurrentHost.RootDir
+		var mount *fs.Mount
+		for _, m := range root.Mounts {
+			if m.Device == args[0] {
+				mount = m
+			}
+		}
+		if dir, ok := state.FindFile(args[0]).(*fs.Directory); ok && mount == nil {
+			mount = root.MountOfRoot(dir)
+		}
+		if mount == nil {
+			return nil, fmt.Errorf("%s: not mounted", args[0])
+		}
+
+		for _, m := range root.Mounts {
+			if m != mount && root.MountOf(m.Point) == mount {
+				return nil, fmt.Errorf("%s: target is busy", args[0])
+			}
+		}
+		if root.MountOf(state.CurrentDir) == mount {
+			return nil, fmt.Errorf("%s: target is busy", args[0])
+		}
+
+		path := state.dirPath(mount.Root)
+		root.Unmount(mount)
+		return []byte(fmt.Sprintf("unmounted %s from %s", mount.Device, path)), nil
+	},
+}
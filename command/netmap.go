@@ -0,0 +1,127 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// discover records that a host has been found from another, so it appears
+// on the map. The host hangs off the closest already discovered host along
+// the path to it. Hosts found by cron jobs aren't the player's to know about.
+func (s *State) discover(from, host *Host, hops []*Hop) {
+	if _, ok := s.Discovered[host]; ok || host == from || s.background {
+		return
+	}
+
+	parent := from
+	for _, hop := range hops {
+		if hop.Host == host {
+			break
+		}
+		if _, ok := s.Discovered[hop.Host]; ok {
+			parent = hop.Host
+		}
+	}
+
+	s.Discovered[host] = parent
+}
+
+// inChain reports whether host is part of the active connection chain
+func (s *State) inChain(host *Host) bool {
+	for _, h := range s.Chain {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) mapLabel(host *Host) string {
+	label := host.Hostname
+	if host.DomainName != "" {
+		label += " " + host.DomainName
+	}
+
+	switch {
+	case host == s.CurrentHost:
+		label += " <- you are here"
+	case host == s.LocalHost:
+		label += " (local)"
+	case s.inChain(host):
+		label += " (connected)"
+	}
+
+	return label
+}
+
+// renderMap draws the discovered hosts as a tree rooted at the local host.
+// Hosts in the connection chain are drawn with heavy lines and marked.
+func (s *State) renderMap() []string {
+	children := map[*Host][]*Host{}
+	for host, parent := range s.Discovered {
+		if parent != nil {
+			children[parent] = append(children[parent], host)
+		}
+	}
+	for _, c := range children {
+		sort.Slice(c, func(i, j int) bool {
+			return c[i].Hostname < c[j].Hostname
+		})
+	}
+
+	lines := []string{s.mapLabel(s.LocalHost)}
+
+	var draw func(host *Host, prefix string)
+	draw = func(host *Host, prefix string) {
+		for i, child := range children[host] {
+			last := i == len(children[host])-1
+
+			branch, indent := "├── ", "│   "
+			if last {
+				branch, indent = "└── ", "    "
+			}
+			if s.inChain(child) {
+				branch = strings.NewReplacer("├", "┝", "└", "┕", "─", "━").Replace(branch)
+			}
+
+			lines = append(lines, prefix+branch+s.mapLabel(child))
+			draw(child, prefix+indent)
+		}
+	}
+	draw(s.LocalHost, "")
+
+	return lines
+}
+
+// mapPane returns the map to show beside the terminal, or nil if the pane
+// is turned off.
+func (s *State) mapPane() []string {
+	if !s.ShowMapPane {
+		return nil
+	}
+	return append([]string{"Network map", ""}, s.renderMap()...)
+}
+
+var MapCommand = &Command{
+	ShortHelp: "Draw a map of the hosts you have discovered",
+	LongHelp: `Draw a map of the hosts you have discovered with scan and portscan, starting from your
+local host. The host you are on and the chain of connections that got you there are marked.
+Use "map pane" to toggle a map that stays on screen beside the terminal.
+Usage: map [pane]`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) > 1 || (len(args) == 1 && args[0] != "pane") {
+			return nil, fmt.Errorf("must supply no arguments or pane")
+		}
+
+		if len(args) == 1 {
+			state.ShowMapPane = !state.ShowMapPane
+			if state.ShowMapPane {
+				return []byte("map pane shown"), nil
+			}
+			return []byte("map pane hidden"), nil
+		}
+
+		return []byte(strings.Join(state.renderMap(), "\n")), nil
+	},
+}
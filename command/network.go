@@ -0,0 +1,357 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"time"
+)
+
+// maxHops is the TTL used when routing between hosts
+const maxHops = 30
+
+// Subnet is a network segment that host interfaces attach to. Latency is the
+// one way delay of crossing it.
+type Subnet struct {
+	CIDR    *net.IPNet
+	Latency time.Duration
+}
+
+// Interface attaches a host to a subnet with an address
+type Interface struct {
+	Name   string
+	IP     net.IP
+	Subnet *Subnet
+}
+
+func (i *Interface) String() string {
+	ones, _ := i.Subnet.CIDR.Mask.Size()
+	return fmt.Sprintf("%s/%d", i.IP, ones)
+}
+
+// Route sends traffic for Dest through Gateway. Subnets a host is attached to
+// are routed directly and don't need an entry.
+type Route struct {
+	Dest    *net.IPNet
+	Gateway net.IP
+}
+
+// Hop is one step along the path between two hosts
+type Hop struct {
+	Host    *Host
+	IP      net.IP
+	Latency time.Duration
+}
+
+// Network holds every host and subnet in the world and works out how
+// traffic gets between them.
+type Network struct {
+	Subnets []*Subnet
+	Hosts   []*Host
+}
+
+func NewNetwork() *Network {
+	return &Network{}
+}
+
+func (n *Network) AddSubnet(cidr *net.IPNet, latency time.Duration) *Subnet {
+	subnet := &Subnet{CIDR: cidr, Latency: latency}
+	n.Subnets = append(n.Subnets, subnet)
+	return subnet
+}
+
+func (n *Network) AddHost(host *Host) {
+	n.Hosts = append(n.Hosts, host)
+}
+
+func (h *Host) AddInterface(name string, ip net.IP, subnet *Subnet) *Interface {
+	iface := &Interface{Name: name, IP: ip, Subnet: subnet}
+	h.Interfaces = append(h.Interfaces, iface)
+	return iface
+}
+
+func (h *Host) AddRoute(dest *net.IPNet, gateway net.IP) {
+	h.Routes = append(h.Routes, &Route{Dest: dest, Gateway: gateway})
+}
+
+// PrimaryIP is the address of the host's first interface, or nil if it has
+// none.
+func (h *Host) PrimaryIP() net.IP {
+	if len(h.Interfaces) == 0 {
+		return nil
+	}
+	return h.Interfaces[0].IP
+}
+
+func (h *Host) HasIP(ip net.IP) bool {
+	for _, iface := range h.Interfaces {
+		if iface.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// interfaceFor returns the interface attached to the subnet containing ip
+func (h *Host) interfaceFor(ip net.IP) *Interface {
+	for _, iface := range h.Interfaces {
+		if iface.Subnet.CIDR.Contains(ip) {
+			return iface
+		}
+	}
+	return nil
+}
+
+// nextHop picks the address traffic for dst is sent to next, using the
+// longest matching prefix from the host's attached subnets and routes.
+func (h *Host) nextHop(dst net.IP) (net.IP, bool) {
+	var best net.IP
+	bestLen := -1
+	for _, iface := range h.Interfaces {
+		if ones, _ := iface.Subnet.CIDR.Mask.Size(); iface.Subnet.CIDR.Contains(dst) && ones > bestLen {
+			best, bestLen = dst, ones
+		}
+	}
+	for _, route := range h.Routes {
+		if ones, _ := route.Dest.Mask.Size(); route.Dest.Contains(dst) && ones > bestLen {
+			best, bestLen = route.Gateway, ones
+		}
+	}
+	return best, bestLen >= 0
+}
+
+// HostByAddress finds a host by its hostname or any of its IPs
+func (n *Network) HostByAddress(address string) *Host {
+	ip := net.ParseIP(address)
+	for _, host := range n.Hosts {
+		if host.Hostname == address || (ip != nil && host.HasIP(ip)) {
+			return host
+		}
+	}
+	return nil
+}
+
+// hostOnSubnet finds the host attached to subnet with the given address
+func (n *Network) hostOnSubnet(subnet *Subnet, ip net.IP) *Host {
+	for _, host := range n.Hosts {
+		for _, iface := range host.Interfaces {
+			if iface.Subnet == subnet && iface.IP.Equal(ip) {
+				return host
+			}
+		}
+	}
+	return nil
+}
+
+// Route works out the path from one host to an address, returning each hop
+// along the way. Only hosts with forwarding enabled pass traffic on.
+func (n *Network) Route(from *Host, dst net.IP) ([]*Hop, error) {
+	hops := []*Hop{}
+	current := from
+	for len(hops) < maxHops {
+		if current.HasIP(dst) {
+			return hops, nil
+		}
+
+		if current != from && !current.Forwarding {
+			return hops, fmt.Errorf("%s: host unreachable", dst)
+		}
+
+		nextIP, ok := current.nextHop(dst)
+		if !ok {
+			return hops, fmt.Errorf("%s: network unreachable", dst)
+		}
+
+		iface := current.interfaceFor(nextIP)
+		if iface == nil {
+			return hops, fmt.Errorf("%s: network unreachable", dst)
+		}
+
+		next := n.hostOnSubnet(iface.Subnet, nextIP)
+		if next == nil {
+			return hops, fmt.Errorf("%s: host unreachable", dst)
+		}
+
+		hops = append(hops, &Hop{Host: next, IP: nextIP, Latency: iface.Subnet.Latency})
+		current = next
+	}
+
+	return hops, fmt.Errorf("%s: time to live exceeded", dst)
+}
+
+// ReachableHosts returns every other host that traffic from the given host
+// can get to, along with the path to each.
+func (n *Network) ReachableHosts(from *Host) map[*Host][]*Hop {
+	reachable := map[*Host][]*Hop{}
+	for _, host := range n.Hosts {
+		if host == from || host.PrimaryIP() == nil {
+			continue
+		}
+		if hops, err := n.Route(from, host.PrimaryIP()); err == nil {
+			reachable[host] = hops
+		}
+	}
+	return reachable
+}
+
+// roundTrip is the simulated round trip time along a path
+func roundTrip(hops []*Hop) time.Duration {
+	var total time.Duration
+	for _, hop := range hops {
+		total += hop.Latency
+	}
+	return 2 * total
+}
+
+func formatLatency(d time.Duration) string {
+	return fmt.Sprintf("%.1f ms", float64(d)/float64(time.Millisecond))
+}
+
+// pingCount is the number of echo requests ping sends
+const pingCount = 4
+
+var PingCommand = &Command{
+	ShortHelp: "Check whether another host can be reached",
+	LongHelp: `Check whether another host can be reached and report the round trip time.
+Usage: ping [hostname or IP]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeServer},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a hostname or IP")
+		}
+
+		host, hops, err := state.routeTo(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		buf := bytes.NewBufferString(fmt.Sprintf("PING %s\n", host.Hostname))
+		if state.checkFirewall(host, 0) != nil {
+			for i := 1; i <= pingCount; i++ {
+				buf.WriteString(fmt.Sprintf("Request timeout for seq=%d\n", i))
+			}
+			buf.WriteString(fmt.Sprintf("%d packets transmitted, 0 received, 100%% packet loss", pingCount))
+			return buf.Bytes(), nil
+		}
+
+		rtt := roundTrip(hops)
+		var total time.Duration
+		for i := 1; i <= pingCount; i++ {
+			// A little deterministic jitter so the replies don't look canned
+			reply := rtt + time.Duration(i%3)*rtt/20
+			total += reply
+			buf.WriteString(fmt.Sprintf("reply from %s: seq=%d ttl=%d time=%s\n", host.Hostname, i, 64-len(hops), formatLatency(reply)))
+		}
+		buf.WriteString(fmt.Sprintf("%d packets transmitted, %d received, 0%% packet loss, avg %s", pingCount, pingCount, formatLatency(total/pingCount)))
+
+		return buf.Bytes(), nil
+	},
+}
+
+var TracerouteCommand = &Command{
+	ShortHelp: "Show the path traffic takes to another host",
+	LongHelp: `Show each hop traffic takes to another host and the round trip time to reach it.
+Usage: traceroute [hostname or IP]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeServer},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a hostname or IP")
+		}
+
+		address, err := state.resolveAddress(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		host := state.Network.HostByAddress(address)
+		if host == nil {
+			return nil, fmt.Errorf("host %s not found", args[0])
+		}
+
+		dst := net.ParseIP(address)
+		if dst == nil || !host.HasIP(dst) {
+			dst = host.PrimaryIP()
+		}
+
+		buf := bytes.NewBufferString(fmt.Sprintf("traceroute to %s, %d hops max\n", args[0], maxHops))
+
+		// Print the hops we got through even if the destination is unreachable
+		hops, routeErr := state.Network.Route(state.CurrentHost, dst)
+		for i := range hops {
+			buf.WriteString(fmt.Sprintf("%2d  %s  %s\n", i+1, hops[i].IP, formatLatency(roundTrip(hops[:i+1]))))
+		}
+		if routeErr != nil {
+			buf.WriteString(fmt.Sprintf("%2d  * * * %v", len(hops)+1, routeErr))
+		}
+
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+	},
+}
+
+func formatInterfaces(host *Host) []byte {
+	if len(host.Interfaces) == 0 {
+		return []byte("No network interfaces.")
+	}
+
+	buf := &bytes.Buffer{}
+	for _, iface := range host.Interfaces {
+		buf.WriteString(fmt.Sprintf("%s: inet %s\n", iface.Name, iface))
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+func formatRoutes(host *Host) []byte {
+	buf := &bytes.Buffer{}
+	for _, iface := range host.Interfaces {
+		buf.WriteString(fmt.Sprintf("%s dev %s\n", iface.Subnet.CIDR, iface.Name))
+	}
+	for _, route := range host.Routes {
+		dest := route.Dest.String()
+		if ones, _ := route.Dest.Mask.Size(); ones == 0 {
+			dest = "default"
+		}
+
+		dev := "?"
+		if iface := host.interfaceFor(route.Gateway); iface != nil {
+			dev = iface.Name
+		}
+		buf.WriteString(fmt.Sprintf("%s via %s dev %s\n", dest, route.Gateway, dev))
+	}
+	if buf.Len() == 0 {
+		return []byte("No routes.")
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+var IfconfigCommand = &Command{
+	ShortHelp: "Show the current host's network interfaces",
+	LongHelp: `Show the current host's network interfaces and their addresses.
+Usage: ifconfig`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("takes no arguments")
+		}
+
+		return formatInterfaces(state.CurrentHost), nil
+	},
+}
+
+var IPCommand = &Command{
+	ShortHelp: "Show the current host's addresses or routing table",
+	LongHelp: `Show the current host's interface addresses or its routing table.
+Usage: ip addr|route`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply addr or route")
+		}
+
+		switch args[0] {
+		case "addr", "a":
+			return formatInterfaces(state.CurrentHost), nil
+		case "route", "r":
+			return formatRoutes(state.CurrentHost), nil
+		}
+
+		return nil, fmt.Errorf("unknown ip command: %s", args[0])
+	},
+}
@@ -0,0 +1,58 @@
+package command
+
+import (
+	"fmt"
+)
+
+var LessCommand = &Command{
+	ShortHelp: "View contents of a file one page at a time",
+	LongHelp: `View contents of a file one page at a time.
+Keys: space/b page forward/back, j/k or arrows scroll a line, g/G jump to start/end,
+/ search, n/N next/previous match, q or esc to quit.
+Usage: less [path to file]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a file path")
+		}
+
+		filePath := args[0]
+
+		contents, err := readFile(state, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		go sendEvent(state.EventChan, EventTypePage, string(contents))
+
+		return nil, nil
+	},
+}
+
+var PagerCommand = &Command{
+	ShortHelp: "Turn automatic paging of long output on or off",
+	LongHelp: `Turn automatic paging of long output on or off. When on, any command output
+taller than the terminal is opened in the pager instead of being printed.
+Usage: pager [on|off]`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) > 1 {
+			return nil, fmt.Errorf("must supply zero or one arguments")
+		}
+
+		if len(args) == 1 {
+			switch args[0] {
+			case "on":
+				state.AutoPage = true
+			case "off":
+				state.AutoPage = false
+			default:
+				return nil, fmt.Errorf("argument must be on or off")
+			}
+		}
+
+		if state.AutoPage {
+			return []byte("automatic paging is on"), nil
+		}
+		return []byte("automatic paging is off"), nil
+	},
+}
@@ -0,0 +1,295 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+// PasswordStrength is how strict a host is about its passwords. Hosts with
+// stricter policies also throttle logins, which slows down attacks.
+type PasswordStrength uint8
+
+const (
+	// PasswordWeak hosts let users pick dictionary words
+	PasswordWeak PasswordStrength = iota
+	// PasswordMedium hosts make users add some digits
+	PasswordMedium
+	// PasswordStrong hosts insist on random passwords
+	PasswordStrong
+)
+
+// loginAttemptTime is how long each guess at a password takes
+var loginAttemptTime = map[PasswordStrength]time.Duration{
+	PasswordWeak:   50 * time.Millisecond,
+	PasswordMedium: 250 * time.Millisecond,
+	PasswordStrong: time.Second,
+}
+
+// DefaultWordlistPath is where crack looks for a wordlist if it isn't given
+// one
+const DefaultWordlistPath = "/usr/share/wordlists/common.txt"
+
+// keyringFile is where passwords the player has learnt are kept, in their
+// home directory on the local host
+const keyringFile = ".keyring"
+
+func (s *State) keyringPath() string {
+	return s.LocalHost.HomeDir(s.LocalUser).FullPath() + "/" + keyringFile
+}
+
+// savedPassword looks up the password the player has saved for a user
+func (s *State) savedPassword(host *Host, userName string) (string, bool) {
+	for _, line := range readConfigFile(s.LocalHost, s.keyringPath()) {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == host.Hostname && fields[1] == userName {
+			return fields[2], true
+		}
+	}
+	return "", false
+}
+
+// savePassword adds a password to the keyring, replacing any saved before
+func (s *State) savePassword(host *Host, userName, password string) error {
+	contents := ""
+	for _, line := range readConfigFile(s.LocalHost, s.keyringPath()) {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == host.Hostname && fields[1] == userName {
+			continue
+		}
+		contents += line + "\n"
+	}
+	contents += fmt.Sprintf("%s %s %s\n", host.Hostname, userName, password)
+	return s.LocalHost.WriteFile(s.keyringPath(), contents, s.Clock.Now())
+}
+
+// authenticate checks the password for logging in to a host as user. If no
+// password is given, the one in the keyring is tried. Passwords that work
+// are saved to the keyring, and failed attempts end up in the host's audit
+// log.
+func (s *State) authenticate(host *Host, user *User, password string) error {
+	if user.Password == "" {
+		return nil
+	}
+
+	given := password != ""
+	if !given {
+		saved, ok := s.savedPassword(host, user.Name)
+		if !ok {
+			return fmt.Errorf("password required for %s@%s", user.Name, host.Hostname)
+		}
+		password = saved
+	}
+
+	if password != user.Password {
+		host.audit(s.Clock.Now(), s.CurrentHost, user.Name, "failed password")
+		return fmt.Errorf("permission denied for %s@%s", user.Name, host.Hostname)
+	}
+
+	// The login still works if the keyring can't be written
+	if given {
+		if err := s.savePassword(host, user.Name, password); err != nil {
+			s.Logf("could not save password to ~/%s: %v", keyringFile, err)
+		}
+	}
+	return nil
+}
+
+// Crack is a password attack running against a user on a host
+type Crack struct {
+	Host     *Host
+	User     string
+	Tried    int
+	Attempts int
+	Process  *Process
+
+	// from is the host the attack is running from
+	from       *Host
+	candidates []string
+	cracked    bool
+	found      string
+	started    time.Time
+}
+
+// bruteForceSuffixes are added to each word in brute force mode
+func bruteForceSuffixes() []string {
+	suffixes := []string{""}
+	for i := 0; i < 10; i++ {
+		suffixes = append(suffixes, fmt.Sprint(i))
+	}
+	for i := 0; i < 100; i++ {
+		suffixes = append(suffixes, fmt.Sprintf("%02d", i))
+	}
+	return suffixes
+}
+
+// startCrack begins guessing the user's password from the candidates. The
+// attack takes as long as the host's login throttling makes it, and stops at
+// the right password or once the candidates run out.
+func (s *State) startCrack(host *Host, userName string, candidates []string) *Crack {
+	crack := &Crack{
+		Host:       host,
+		User:       userName,
+		Attempts:   len(candidates),
+		Process:    s.CurrentHost.StartProcess(s.CurrentUser.Name, fmt.Sprintf("crack %s %s", host.Hostname, userName), 99, s.Clock.Now()),
+		from:       s.CurrentHost,
+		candidates: candidates,
+		started:    s.Clock.Now(),
+	}
+
+	if user, ok := host.Users[userName]; ok && user.Password == "" {
+		crack.Attempts, crack.cracked = 1, true
+	} else if ok {
+		for i, candidate := range candidates {
+			if candidate == user.Password {
+				crack.Attempts, crack.cracked, crack.found = i+1, true, candidate
+				break
+			}
+		}
+	}
+
+	crack.Process.onKill = func() {
+		if s.Crack == crack {
+			s.Crack = nil
+			s.Logf("crack: attack on %s@%s cancelled after %d attempts", userName, host.Hostname, crack.Tried)
+		}
+	}
+
+	s.Crack = crack
+	s.scheduleCrackTick(crack)
+	return crack
+}
+
+func (s *State) scheduleCrackTick(crack *Crack) {
+	s.after(time.Second, func() {
+		if s.Crack != crack {
+			return
+		}
+
+		crack.Tried = int(s.Clock.Now().Sub(crack.started) / loginAttemptTime[crack.Host.PasswordStrength])
+		if crack.Tried < crack.Attempts {
+			s.scheduleCrackTick(crack)
+			return
+		}
+
+		crack.Tried = crack.Attempts
+		s.finishCrack(crack)
+	})
+}
+
+func (s *State) finishCrack(crack *Crack) {
+	s.Crack = nil
+	crack.from.endProcess(crack.Process)
+
+	failed := crack.Attempts
+	if crack.cracked {
+		failed--
+	}
+	if failed > 0 {
+		crack.Host.audit(s.Clock.Now(), crack.from, crack.User, fmt.Sprintf("%d failed passwords", failed))
+	}
+
+	if !crack.cracked {
+		s.Logf("crack: tried %d passwords without finding %s@%s's", crack.Attempts, crack.User, crack.Host.Hostname)
+		return
+	}
+	if crack.found == "" {
+		s.Logf("crack: %s@%s has no password", crack.User, crack.Host.Hostname)
+		return
+	}
+
+	if err := s.savePassword(crack.Host, crack.User, crack.found); err != nil {
+		s.Logf("crack: password for %s@%s is %q, but saving it to ~/%s failed: %v", crack.User, crack.Host.Hostname, crack.found, keyringFile, err)
+		return
+	}
+	s.Logf("crack: password for %s@%s is %q, saved to ~/%s", crack.User, crack.Host.Hostname, crack.found, keyringFile)
+}
+
+// status shows the progress of the attack
+func (c *Crack) status() string {
+	filled := traceBarWidth * c.Tried / len(c.candidates)
+	return fmt.Sprintf("CRACK %s@%s [%s%s] %d/%d", c.User, c.Host.Hostname,
+		strings.Repeat("#", filled), strings.Repeat("-", traceBarWidth-filled), c.Tried, len(c.candidates))
+}
+
+var CrackCommand = &Command{
+	ShortHelp: "Guess a user's password on another host",
+	LongHelp: `Guess a user's password on another host by trying every word in a wordlist, over ssh.
+With -b each word is also tried with one or two digits on the end, which takes far longer.
+Hosts with strict password policies throttle logins, slowing the attack down. Found
+passwords are saved to ~/.keyring for connect and scp to use. Only one attack can run at
+a time; stop it with crack stop or by killing its process.
+Usage: crack [-b] [hostname] [user] [wordlist] | crack stop`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeServer},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		// The attack belongs to the player's session, which a cron job's
+		// copy of it can't reach
+		if state.background {
+			return nil, fmt.Errorf("crack can't be run in the background")
+		}
+
+		if len(args) == 1 && args[0] == "stop" {
+			if state.Crack == nil {
+				return nil, fmt.Errorf("no attack running")
+			}
+			crack := state.Crack
+			crack.from.endProcess(crack.Process)
+			crack.Process.onKill()
+			return nil, nil
+		}
+
+		flags, args, err := parseFlags(args, "b")
+		if err != nil {
+			return nil, err
+		}
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("must supply a hostname, user and optionally a wordlist")
+		}
+		if state.Crack != nil {
+			return nil, fmt.Errorf("an attack on %s@%s is already running", state.Crack.User, state.Crack.Host.Hostname)
+		}
+
+		wordlistPath := DefaultWordlistPath
+		if len(args) == 3 {
+			wordlistPath = args[2]
+		}
+		wordlist, ok := state.FindFile(wordlistPath).(*fs.Text)
+		if !ok {
+			return nil, fmt.Errorf("wordlist %s not found", wordlistPath)
+		}
+
+		host, _, err := state.routeTo(args[0])
+		if err != nil {
+			return nil, err
+		}
+		ssh := host.ServiceOfType(ServiceTypeSSH)
+		if ssh == nil {
+			return nil, fmt.Errorf("connection to %s refused: no ssh service running", host.Hostname)
+		}
+		if err := state.checkFirewall(host, ssh.Port); err != nil {
+			return nil, err
+		}
+
+		suffixes := []string{""}
+		if flags['b'] {
+			suffixes = bruteForceSuffixes()
+		}
+		candidates := []string{}
+		for _, word := range configLines(wordlist.Contents) {
+			for _, suffix := range suffixes {
+				candidates = append(candidates, word+suffix)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("wordlist %s is empty", wordlistPath)
+		}
+
+		crack := state.startCrack(host, args[1], candidates)
+		estimate := time.Duration(len(candidates)) * loginAttemptTime[host.PasswordStrength]
+
+		return []byte(fmt.Sprintf("Attacking %s@%s with %d passwords (pid %d), up to %s...",
+			args[1], host.Hostname, len(candidates), crack.Process.PID, estimate.Round(time.Second))), nil
+	},
+}
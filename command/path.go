@@ -0,0 +1,117 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+// expandPath replaces a leading ~ or ~user with the matching home directory
+// on the current host. Unknown users are left untouched.
+func (s *State) expandPath(path string) string {
+	if !strings.HasPrefix(path, "~") {
+		return path
+	}
+
+	name := strings.TrimPrefix(path, "~")
+	rest := ""
+	if idx := strings.Index(name, "/"); idx >= 0 {
+		name, rest = name[:idx], name[idx:]
+	}
+
+	user := s.CurrentUser
+	if name != "" {
+		var ok bool
+		if user, ok = s.CurrentHost.Users[name]; !ok {
+			return path
+		}
+	}
+
+	return s.CurrentHost.HomeDir(user).FullPath() + rest
+}
+
+// FindFile looks up a path relative to the current directory on the current
+// host, expanding ~ first. Returns nil if the file is not found.
+func (s *State) FindFile(path string) fs.File {
+	return fs.FindFileRelative(s.CurrentDir, s.CurrentHost.RootDir, s.expandPath(path))
+}
+
+// FindFileNoFollow is like FindFile but does not follow a symbolic link in
+// the last part of the path.
+func (s *State) FindFileNoFollow(path string) fs.File {
+	return fs.FindFileRelativeNoFollow(s.CurrentDir, s.CurrentHost.RootDir, s.expandPath(path))
+}
+
+// findNewFilePath finds the directory a new file at path should be created
+// in, returning it along with the name of the new file. It fails if the
+// directory does not exist or something already exists at path.
+func (s *State) findNewFilePath(path string) (*fs.Directory, string, error) {
+	if s.FindFileNoFollow(path) != nil {
+		return nil, "", fmt.Errorf("file or directory %s already exists", path)
+	}
+
+	dir := s.CurrentDir
+	name := path
+	if idx := strings.LastIndex(path, "/"); idx >= 0 {
+		name = path[idx+1:]
+		foundDir, ok := s.FindFile(path[:idx+1]).(*fs.Directory)
+		if !ok {
+			return nil, "", fmt.Errorf("file path not valid - directory does not exist")
+		}
+		dir = foundDir
+	}
+
+	if err := fs.ValidateFileName(name); err != nil {
+		return nil, "", err
+	}
+
+	return dir, name, nil
+}
+
+// dirOf finds the directory the last part of path is in
+func (s *State) dirOf(path string) *fs.Directory {
+	if idx := strings.LastIndex(path, "/"); idx >= 0 {
+		if dir, ok := s.FindFile(path[:idx+1]).(*fs.Directory); ok {
+			return dir
+		}
+	}
+	return s.CurrentDir
+}
+
+// dirPath is the absolute path of dir on the current host, through any
+// mount points
+func (s *State) dirPath(dir *fs.Directory) string {
+	return fs.PathOf(dir, s.CurrentHost.RootDir)
+}
+
+// parentDir is the directory above dir on the current host, or nil at the
+// root
+func (s *State) parentDir(dir *fs.Directory) *fs.Directory {
+	return fs.ParentDir(dir, s.CurrentHost.RootDir)
+}
+
+// addFile places a newly created file into dir, if the current host has room
+// for it
+func (s *State) addFile(dir *fs.Directory, file fs.File) error {
+	size := file.Size()
+	if existing, ok := dir.Files[strings.ToLower(file.Name())]; ok {
+		size -= existing.Size()
+	}
+	if err := s.reserve(dir, size); err != nil {
+		return err
+	}
+
+	s.putFile(dir, file)
+	return nil
+}
+
+// putFile places a file in dir without checking for space, for entries such
+// as hard links that take up none of their own.
+func (s *State) putFile(dir *fs.Directory, file fs.File) {
+	if d, ok := file.(*fs.Directory); ok {
+		d.Parent = dir
+	}
+	dir.Files[strings.ToLower(file.Name())] = file
+	dir.Modified = s.Clock.Now()
+}
@@ -0,0 +1,364 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/clock"
+)
+
+// idsDaemon is the name of the intrusion detection process
+const idsDaemon = "snort"
+
+// daemonNames are the processes that run each type of service
+var daemonNames = map[ServiceType]string{
+	ServiceTypeSSH:  "sshd",
+	ServiceTypeFTP:  "ftpd",
+	ServiceTypeHTTP: "httpd",
+	ServiceTypeDB:   "dbd",
+	ServiceTypeDNS:  "named",
+	ServiceTypeNFS:  "nfsd",
+}
+
+// Process is a program running on a host
+type Process struct {
+	PID     int
+	User    string
+	Name    string
+	Started time.Time
+	CPU     float64
+
+	// onKill undoes whatever the process was doing when it is killed
+	onKill func()
+}
+
+// StartProcess adds a process to the host's process table
+func (h *Host) StartProcess(user, name string, cpu float64, now time.Time) *Process {
+	h.lastPID++
+	p := &Process{PID: h.lastPID, User: user, Name: name, Started: now, CPU: cpu}
+	h.Processes = append(h.Processes, p)
+	return p
+}
+
+func (h *Host) processByPID(pid int) *Process {
+	for _, p := range h.Processes {
+		if p.PID == pid {
+			return p
+		}
+	}
+	return nil
+}
+
+// endProcess removes a process from the table without running its kill hook
+func (h *Host) endProcess(p *Process) {
+	for i, other := range h.Processes {
+		if other == p {
+			h.Processes = append(h.Processes[:i], h.Processes[i+1:]...)
+			return
+		}
+	}
+}
+
+// startDaemon runs a service's daemon. Killing it takes the service down.
+func (h *Host) startDaemon(service *Service) {
+	p := h.StartProcess("root", daemonNames[service.Type], 0.1*float64(len(h.Processes)%4), h.RootDir.Created)
+	p.onKill = func() {
+		if h.Services[service.Port] == service {
+			delete(h.Services, service.Port)
+		}
+	}
+}
+
+// SetIDSLevel sets how closely the host watches for intruders, starting the
+// intrusion detection daemon that does the watching. Killing the daemon
+// turns detection off.
+func (h *Host) SetIDSLevel(level int) {
+	h.IDSLevel = level
+	if level <= 0 {
+		return
+	}
+
+	p := h.StartProcess("root", idsDaemon, 2.5*float64(level), h.RootDir.Created)
+	p.onKill = func() {
+		h.IDSLevel = 0
+	}
+}
+
+// Job is a command the player is running in the background
+type Job struct {
+	ID      int
+	Line    string
+	Host    *Host
+	Process *Process
+
+	// name is the command the line runs, once aliases and programs are
+	// looked up
+	name    string
+	session *State
+	timer   *clock.Timer
+}
+
+// jobTable holds the player's background jobs. It is kept behind a pointer
+// so that copies of the session see the same jobs rather than forking them.
+type jobTable struct {
+	jobs   []*Job
+	lastID int
+}
+
+// jobDuration is how long background jobs take to finish
+const jobDuration = 5 * time.Second
+
+// startJob runs a line in the background, in a copy of the current session
+// so the player can carry on. The command runs when the job finishes.
+func (s *State) startJob(line string) ([]byte, error) {
+	cmdName, args, err := readLine([]rune(line))
+	if err != nil {
+		return nil, err
+	}
+	if cmdName, _, err = s.expandAlias(cmdName, args); err != nil {
+		return nil, err
+	}
+	name, _, err := s.lookupCommand(cmdName)
+	if err != nil {
+		return nil, err
+	}
+	if s.background || s.job || interactiveCommands[name] {
+		return nil, fmt.Errorf("%s can't be run in the background", cmdName)
+	}
+
+	session := *s
+	session.job = true
+	s.jobs.lastID++
+	job := &Job{
+		ID:      s.jobs.lastID,
+		Line:    line,
+		Host:    s.CurrentHost,
+		name:    name,
+		Process: s.CurrentHost.StartProcess(s.CurrentUser.Name, line, 25, s.Clock.Now()),
+		session: &session,
+	}
+	job.Process.onKill = func() {
+		s.Clock.Stop(job.timer)
+		s.endJob(job)
+		s.Logf("[%d]+ Terminated  %s", job.ID, job.Line)
+	}
+	job.timer = s.after(jobDuration, func() {
+		s.finishJob(job)
+	})
+	s.jobs.jobs = append(s.jobs.jobs, job)
+
+	return []byte(fmt.Sprintf("[%d] %d", job.ID, job.Process.PID)), nil
+}
+
+// runJob runs the job's command and removes it from the job table. Trace
+// noise and missions are left to the player's own session, as a trace caught
+// in the job's copy would disconnect nobody.
+func (s *State) runJob(job *Job) ([]byte, error) {
+	s.endJob(job)
+	output, err := job.session.Run(job.Line)
+	if err == nil {
+		s.traceNoise(job.name)
+	}
+	s.checkMissions()
+	return output, err
+}
+
+func (s *State) hasJob(job *Job) bool {
+	for _, other := range s.jobs.jobs {
+		if other == job {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) endJob(job *Job) {
+	job.Host.endProcess(job.Process)
+	for i, other := range s.jobs.jobs {
+		if other == job {
+			s.jobs.jobs = append(s.jobs.jobs[:i], s.jobs.jobs[i+1:]...)
+			return
+		}
+	}
+}
+
+// finishJob runs a job that has run its course and reports how it went
+func (s *State) finishJob(job *Job) {
+	// It may have been brought to the foreground or killed while this
+	// was on its way
+	if !s.hasJob(job) {
+		return
+	}
+
+	output, err := s.runJob(job)
+
+	lines := []string{fmt.Sprintf("[%d]+ Done  %s", job.ID, job.Line)}
+	if err != nil {
+		lines[0] = fmt.Sprintf("[%d]+ Exit 1  %s", job.ID, job.Line)
+		lines = append(lines, fmt.Sprintf("error: %v", err))
+	} else if len(output) > 0 {
+		lines = append(lines, strings.Split(string(output), "\n")...)
+	}
+	s.logLines(lines...)
+}
+
+// jobArg finds the job named by %n or n, or the latest if arg is empty
+func (s *State) jobArg(arg string) (*Job, error) {
+	jobs := s.jobs.jobs
+	if s.background || len(jobs) == 0 {
+		return nil, fmt.Errorf("no current job")
+	}
+	if arg == "" {
+		return jobs[len(jobs)-1], nil
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(arg, "%"))
+	if err == nil {
+		for _, job := range jobs {
+			if job.ID == id {
+				return job, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s: no such job", arg)
+}
+
+func formatProcesses(processes []*Process) []byte {
+	buf := bytes.NewBufferString(fmt.Sprintf("%5s %-8s %5s %-12s %s\n", "PID", "USER", "%CPU", "STARTED", "COMMAND"))
+	for _, p := range processes {
+		buf.WriteString(fmt.Sprintf("%5d %-8s %5.1f %-12s %s\n", p.PID, p.User, p.CPU, p.Started.Format("Jan 02 15:04"), p.Name))
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+func formatUptime(d time.Duration) string {
+	minutes := int(d.Minutes())
+	return fmt.Sprintf("%d days, %d:%02d", minutes/(24*60), minutes/60%24, minutes%60)
+}
+
+var PSCommand = &Command{
+	ShortHelp: "List the processes running on the current host",
+	LongHelp: `List every process running on the current host.
+Usage: ps`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("takes no arguments")
+		}
+
+		return formatProcesses(state.CurrentHost.Processes), nil
+	},
+}
+
+var TopCommand = &Command{
+	ShortHelp: "Show the busiest processes on the current host",
+	LongHelp: `Show how long the current host has been up and its processes, busiest first.
+Usage: top`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("takes no arguments")
+		}
+
+		host := state.CurrentHost
+		processes := append([]*Process{}, host.Processes...)
+		sort.SliceStable(processes, func(i, j int) bool {
+			return processes[i].CPU > processes[j].CPU
+		})
+
+		var load float64
+		for _, p := range processes {
+			load += p.CPU
+		}
+
+		now := state.Clock.Now()
+		header := fmt.Sprintf("top - %s up %s, %d processes, load average: %.2f\n",
+			now.Format("15:04:05"), formatUptime(now.Sub(host.RootDir.Created)), len(processes), load/100)
+
+		return append([]byte(header), formatProcesses(processes)...), nil
+	},
+}
+
+var KillCommand = &Command{
+	ShortHelp: "Stop a process or background job",
+	LongHelp: `Stop a process on the current host by its PID, or one of your background jobs with %n.
+Killing a service's daemon takes the service down. Only root can kill other users' processes.
+Usage: kill [pid|%job]`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a pid or job")
+		}
+
+		host := state.CurrentHost
+		var p *Process
+		if strings.HasPrefix(args[0], "%") {
+			job, err := state.jobArg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			host, p = job.Host, job.Process
+		} else {
+			pid, err := strconv.Atoi(args[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid pid %s", args[0])
+			}
+			if p = host.processByPID(pid); p == nil {
+				return nil, fmt.Errorf("no such process %d", pid)
+			}
+		}
+
+		if p.PID == 1 || (state.CurrentUser.Name != "root" && state.CurrentUser.Name != p.User) {
+			return nil, fmt.Errorf("kill %d: operation not permitted", p.PID)
+		}
+
+		host.endProcess(p)
+		if p.onKill != nil {
+			p.onKill()
+		}
+		state.updateTrace()
+
+		return nil, nil
+	},
+}
+
+var JobsCommand = &Command{
+	ShortHelp: "List your background jobs",
+	LongHelp: `List the commands you are running in the background with &.
+Usage: jobs`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("takes no arguments")
+		}
+
+		buf := &bytes.Buffer{}
+		for _, job := range state.jobs.jobs {
+			buf.WriteString(fmt.Sprintf("[%d]  Running  %s (%s pid %d)\n", job.ID, job.Line, job.Host.Hostname, job.Process.PID))
+		}
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+	},
+}
+
+var FGCommand = &Command{
+	ShortHelp: "Bring a background job to the foreground",
+	LongHelp: `Finish a background job now and show its output. Without a job, the latest is used.
+Usage: fg [%job]`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) > 1 {
+			return nil, fmt.Errorf("must supply zero or one jobs")
+		}
+
+		arg := ""
+		if len(args) == 1 {
+			arg = args[0]
+		}
+		job, err := state.jobArg(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		state.Clock.Stop(job.timer)
+		return state.runJob(job)
+	},
+}
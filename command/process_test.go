@@ -0,0 +1,62 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ckiely91/shellsim/clock"
+)
+
+func TestJobTraceNoise(t *testing.T) {
+	clk := clock.NewFake(clock.Epoch)
+	network, localHost := defaultWorld(clk.Now())
+	state := NewStateWithNetwork(clk, network, localHost)
+
+	var host *Host
+	for _, other := range network.Hosts {
+		if other != localHost && other.Commands == nil {
+			host = other
+			break
+		}
+	}
+	host.IDSLevel = 1
+	state.Login(host, host.Users["root"])
+	state.Chain = []*Host{host}
+	state.Trace = &Trace{Host: host, Progress: 9 * time.Second, Limit: 10 * time.Second, last: clk.Now()}
+
+	if _, err := state.Run("mkdir loud &"); err != nil {
+		t.Fatal(err)
+	}
+	if state.Trace == nil || state.Trace.Progress != 9*time.Second {
+		t.Fatal("starting the job made noise before it ran")
+	}
+
+	clk.Advance(jobDuration)
+
+	// The noise from the finished job completes the player's trace, not one
+	// in the job's copy of the session
+	if state.Trace != nil || state.Traces != 1 {
+		t.Fatalf("trace %v after %d traces, want the player traced", state.Trace, state.Traces)
+	}
+	if state.CurrentHost != localHost || state.Chain != nil {
+		t.Fatal("the player wasn't disconnected")
+	}
+}
+
+func TestInteractiveCommandsInBackground(t *testing.T) {
+	clk := clock.NewFake(clock.Epoch)
+	network, localHost := defaultWorld(clk.Now())
+	state := NewStateWithNetwork(clk, network, localHost)
+
+	for name := range interactiveCommands {
+		_, command := state.Commands[name]
+		_, program := state.Programs[name]
+		if !command && !program {
+			t.Errorf("%s isn't a command or program", name)
+			continue
+		}
+		if _, err := state.Run(name + " &"); err == nil {
+			t.Errorf("%s was run in the background", name)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+const (
+	// environmentPath is the file a host's PATH is read from
+	environmentPath = "/etc/environment"
+	// defaultPath is searched for programs on hosts that don't set PATH
+	defaultPath = "/usr/local/bin:/usr/bin:/bin"
+	// installDir is where install puts programs
+	installDir = "/usr/local/bin"
+	// programSize is how much disk space an installed program takes
+	programSize = 48 * 1024
+)
+
+// standardPrograms are the commands that only run where they are installed,
+// as executable files on the host. Everything else is built in to the shell.
+func standardPrograms() map[string]*Command {
+	return map[string]*Command{
+		"portscan": PortscanCommand,
+		"crack":    CrackCommand,
+	}
+}
+
+// InstallProgram puts an executable for a program in dir on the host
+func (h *Host) InstallProgram(dir, program string, now time.Time) error {
+	d, err := fs.MkdirAll(h.RootDir, dir, now)
+	if err != nil {
+		return err
+	}
+
+	d.Files[strings.ToLower(program)] = fs.NewExecutable(program, program, programSize, now)
+	d.Modified = now
+	return nil
+}
+
+// searchPath returns the directories searched for programs on the current
+// host, in order, from the PATH set in its /etc/environment.
+func (s *State) searchPath() []string {
+	path := defaultPath
+	for _, line := range readConfigFile(s.CurrentHost, environmentPath) {
+		if value, ok := strings.CutPrefix(line, "PATH="); ok {
+			path = strings.Trim(value, `"'`)
+		}
+	}
+
+	dirs := []string{}
+	for _, dir := range strings.Split(path, ":") {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// installedPrograms finds the programs on the current host's PATH, by the
+// names they are installed under. Earlier directories win.
+func (s *State) installedPrograms() map[string]*Command {
+	programs := map[string]*Command{}
+	root := s.CurrentHost.RootDir
+	for _, dir := range s.searchPath() {
+		d, ok := fs.FindFileRelative(root, root, dir).(*fs.Directory)
+		if !ok {
+			continue
+		}
+
+		for _, file := range d.Files {
+			exe, ok := file.(*fs.Executable)
+			if !ok {
+				continue
+			}
+			if _, seen := programs[exe.Name()]; seen {
+				continue
+			}
+			if program, ok := s.Programs[exe.Program]; ok {
+				programs[exe.Name()] = program
+			}
+		}
+	}
+	return programs
+}
+
+// availableCommands returns every command that can be run on the current
+// host by name, built in or installed.
+func (s *State) availableCommands() map[string]*Command {
+	commands := map[string]*Command{}
+	if !s.CurrentHost.Restricted {
+		commands = s.installedPrograms()
+	}
+	for name, cmd := range s.builtinCommands() {
+		commands[name] = cmd
+	}
+	return commands
+}
+
+// lookupCommand finds what to run for cmdName: a command built in to the
+// current host's shell, a program on the PATH or, if cmdName is a path, the
+// executable there. It also returns the name of the command found, which
+// differs from cmdName for programs run by path or installed under another
+// name.
+func (s *State) lookupCommand(cmdName string) (string, *Command, error) {
+	if cmd, ok := s.builtinCommands()[cmdName]; ok {
+		return cmdName, cmd, nil
+	}
+	if s.CurrentHost.Restricted {
+		return "", nil, invalidCommandError{name: cmdName}
+	}
+
+	var exe *fs.Executable
+	if strings.Contains(cmdName, "/") {
+		file := s.FindFile(cmdName)
+		if file == nil {
+			return "", nil, fmt.Errorf("%s: no such file or directory", cmdName)
+		}
+		var ok bool
+		if exe, ok = file.(*fs.Executable); !ok {
+			return "", nil, fmt.Errorf("%s: permission denied", cmdName)
+		}
+	} else {
+		root := s.CurrentHost.RootDir
+		for _, dir := range s.searchPath() {
+			if file, ok := fs.FindFileRelative(root, root, dir+"/"+cmdName).(*fs.Executable); ok {
+				exe = file
+				break
+			}
+		}
+		if exe == nil {
+			return "", nil, invalidCommandError{name: cmdName}
+		}
+	}
+
+	cmd, ok := s.Programs[exe.Program]
+	if !ok {
+		return "", nil, fmt.Errorf("%s: cannot execute binary file", cmdName)
+	}
+	return exe.Program, cmd, nil
+}
+
+var InstallCommand = &Command{
+	ShortHelp: "Install a program on the current host",
+	LongHelp: `Install a program file on the current host by copying it to /usr/local/bin, so it can be run
+by name. Programs can be copied from other hosts with scp. Only root can install programs.
+Usage: install [path]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a file path")
+		}
+		if state.CurrentUser.Name != "root" {
+			return nil, fmt.Errorf("only root can install programs")
+		}
+
+		file := state.FindFile(args[0])
+		if file == nil {
+			return nil, fmt.Errorf("file not found")
+		}
+		exe, ok := file.(*fs.Executable)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a program", args[0])
+		}
+
+		now := state.Clock.Now()
+		dir, err := fs.MkdirAll(state.CurrentHost.RootDir, installDir, now)
+		if err != nil {
+			return nil, err
+		}
+		if existing, ok := dir.Files[strings.ToLower(exe.Name())]; ok && existing.Type() == fs.FileTypeDirectory {
+			return nil, fmt.Errorf("%s/%s is a directory", installDir, exe.Name())
+		}
+
+		if err := state.addFile(dir, fs.NewExecutable(exe.Name(), exe.Program, exe.Length, now)); err != nil {
+			return nil, err
+		}
+
+		return []byte(fmt.Sprintf("installed %s to %s/%s", exe.Name(), installDir, exe.Name())), nil
+	},
+}
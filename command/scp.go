@@ -0,0 +1,150 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+// splitRemotePath splits an argument of the form [user@]host:path. ok is
+// false if the argument is a plain local path.
+func splitRemotePath(arg string) (userName, hostname, path string, ok bool) {
+	idx := strings.Index(arg, ":")
+	if idx < 0 || strings.Contains(arg[:idx], "/") {
+		return "", "", arg, false
+	}
+
+	userName, hostname, path = "root", arg[:idx], arg[idx+1:]
+	if at := strings.Index(hostname, "@"); at >= 0 {
+		userName, hostname = hostname[:at], hostname[at+1:]
+	}
+
+	return userName, hostname, path, true
+}
+
+// remoteSession returns a copy of the state logged in to another host, used
+// to resolve paths there without moving the player's own session.
+func (s *State) remoteSession(userName, hostname string) (*State, error) {
+	host, err := s.findHost(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	ssh := host.ServiceOfType(ServiceTypeSSH)
+	if ssh == nil {
+		return nil, fmt.Errorf("connection to %s refused: no ssh service running", host.Hostname)
+	}
+
+	if err := s.checkFirewall(host, ssh.Port); err != nil {
+		return nil, err
+	}
+
+	user, ok := host.Users[userName]
+	if !ok {
+		return nil, fmt.Errorf("no such user %s on %s", userName, host.Hostname)
+	}
+
+	if err := s.authenticate(host, user, ""); err != nil {
+		return nil, err
+	}
+
+	host.audit(s.Clock.Now(), s.CurrentHost, user.Name, "scp")
+
+	remote := *s
+	remote.Login(host, user)
+	return &remote, nil
+}
+
+// copyFile makes an independent copy of a file so that changes to one do not
+// affect the other.
+func copyFile(file fs.File, name string, now time.Time) (fs.File, error) {
+	if file.Type() == fs.FileTypeDirectory {
+		return nil, fmt.Errorf("%s is a directory", file.Name())
+	}
+	return fs.Copy(file, name, now)
+}
+
+var SCPCommand = &Command{
+	ShortHelp: "Copy files to or from another host",
+	LongHelp: `Copy files to or from another host over ssh. Exactly one of the source and destination
+must be remote, written as [user@]host:path. Remote paths are relative to the user's home
+directory. The remote host must run an ssh service that its firewall lets you reach, and
+the user's password must be saved in ~/.keyring if they have one.
+Usage: scp [source] [destination]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("must supply a source and a destination")
+		}
+
+		srcUser, srcHost, srcPath, srcRemote := splitRemotePath(args[0])
+		dstUser, dstHost, dstPath, dstRemote := splitRemotePath(args[1])
+		if srcRemote == dstRemote {
+			return nil, fmt.Errorf("exactly one of the source and destination must be remote")
+		}
+
+		src, dst := state, state
+		var err error
+		if srcRemote {
+			src, err = state.remoteSession(srcUser, srcHost)
+		} else {
+			dst, err = state.remoteSession(dstUser, dstHost)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		srcFile := src.FindFile(srcPath)
+		if srcFile == nil {
+			return nil, fmt.Errorf("%s: file not found", args[0])
+		}
+
+		now := state.Clock.Now()
+
+		// Copying onto an existing directory puts the file inside it
+		var dstDir *fs.Directory
+		dstName := srcFile.Name()
+		existing := dst.FindFile(dstPath)
+		if dir, ok := existing.(*fs.Directory); ok {
+			dstDir = dir
+			existing = dstDir.Files[strings.ToLower(dstName)]
+		}
+
+		if existing != nil {
+			existingText, ok := existing.(*fs.Text)
+			srcText, srcOk := srcFile.(*fs.Text)
+			if !ok || !srcOk {
+				return nil, fmt.Errorf("%s: cannot overwrite %s", args[1], existing.Name())
+			}
+
+			dir := dstDir
+			if dir == nil {
+				dir = dst.dirOf(dstPath)
+			}
+			if err := dst.reserve(dir, srcText.Size()-existingText.Size()); err != nil {
+				return nil, err
+			}
+			existingText.Contents = append([]byte{}, srcText.Contents...)
+			existingText.Modified = now
+			return []byte(fmt.Sprintf("copied %s to %s", args[0], args[1])), nil
+		}
+
+		if dstDir == nil {
+			if dstDir, dstName, err = dst.findNewFilePath(dstPath); err != nil {
+				return nil, err
+			}
+		}
+
+		copied, err := copyFile(srcFile, dstName, now)
+		if err != nil {
+			return nil, err
+		}
+		if err := dst.addFile(dstDir, copied); err != nil {
+			return nil, err
+		}
+
+		return []byte(fmt.Sprintf("copied %s to %s", args[0], args[1])), nil
+	},
+}
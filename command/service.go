@@ -0,0 +1,125 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ServiceType uint8
+
+const (
+	ServiceTypeSSH ServiceType = iota
+	ServiceTypeFTP
+	ServiceTypeHTTP
+	ServiceTypeDB
+	ServiceTypeDNS
+	ServiceTypeNFS
+)
+
+var serviceTypeNames = map[ServiceType]string{
+	ServiceTypeSSH:  "ssh",
+	ServiceTypeFTP:  "ftp",
+	ServiceTypeHTTP: "http",
+	ServiceTypeDB:   "db",
+	ServiceTypeDNS:  "dns",
+	ServiceTypeNFS:  "nfs",
+}
+
+func (t ServiceType) String() string {
+	if name, ok := serviceTypeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Service is a network service listening on a port of a host
+type Service struct {
+	Port    int
+	Type    ServiceType
+	Version string
+	Banner  string
+}
+
+func (h *Host) AddService(port int, serviceType ServiceType, version, banner string) *Service {
+	service := &Service{
+		Port:    port,
+		Type:    serviceType,
+		Version: version,
+		Banner:  banner,
+	}
+	h.Services[port] = service
+	h.startDaemon(service)
+	return service
+}
+
+// ServiceOfType returns the lowest numbered port running the given type of
+// service, or nil if the host has none.
+func (h *Host) ServiceOfType(serviceType ServiceType) *Service {
+	var found *Service
+	for _, service := range h.Services {
+		if service.Type == serviceType && (found == nil || service.Port < found.Port) {
+			found = service
+		}
+	}
+	return found
+}
+
+// SortedServices returns the host's services ordered by port
+func (h *Host) SortedServices() []*Service {
+	services := []*Service{}
+	for _, service := range h.Services {
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Port < services[j].Port
+	})
+	return services
+}
+
+// portScanDelay is how long the simulated scan spends probing each port
+const portScanDelay = 400 * time.Millisecond
+
+var PortscanCommand = &Command{
+	ShortHelp: "Scan a host for open ports and running services",
+	LongHelp: `Scan a host for open ports and running services. The scan runs in the background
+and reports each port as it is found. Ports blocked by a firewall are shown as filtered.
+Usage: portscan [hostname]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeServer},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("must supply a hostname")
+		}
+
+		host, hops, err := state.routeTo(args[0])
+		if err != nil {
+			return nil, err
+		}
+		state.discover(state.CurrentHost, host, hops)
+
+		// Take a copy of what we report now, as the scan finishes later
+		lines := []string{}
+		for _, service := range host.SortedServices() {
+			if state.checkFirewall(host, service.Port) != nil {
+				lines = append(lines, fmt.Sprintf("  %d/tcp filtered", service.Port))
+				continue
+			}
+
+			line := fmt.Sprintf("  %d/tcp open %s %s", service.Port, service.Type, service.Version)
+			if service.Banner != "" {
+				line += fmt.Sprintf(" - %q", service.Banner)
+			}
+			lines = append(lines, strings.TrimRight(line, " "))
+		}
+		hostname := host.Hostname
+
+		for i, line := range lines {
+			state.scheduleEvent(time.Duration(i+1)*portScanDelay, EventTypeLog, line)
+		}
+		state.scheduleEvent(time.Duration(len(lines)+1)*portScanDelay, EventTypeLog,
+			fmt.Sprintf("Port scan of %s complete: %d ports found", hostname, len(lines)))
+
+		return []byte(fmt.Sprintf("Starting port scan of %s...", hostname)), nil
+	},
+}
@@ -0,0 +1,223 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+const (
+	shellRCFileName = ".shellrc"
+	aliasesFileName = ".aliases"
+)
+
+type invalidCommandError struct {
+	name string
+}
+
+func (e invalidCommandError) Error() string {
+	return fmt.Sprintf("invalid command: %s", e.name)
+}
+
+// Run parses a single line of input, expands any alias defined for the
+// current host and executes the resulting command, then checks whether it
+// completed any mission objectives. Cron and job sessions leave missions to
+// the player's own session.
+func (s *State) Run(line string) ([]byte, error) {
+	if trimmed := strings.TrimSpace(line); strings.HasSuffix(trimmed, "&") {
+		if s.GameOver {
+			return nil, fmt.Errorf("game over: press Esc to quit")
+		}
+		return s.startJob(strings.TrimSpace(strings.TrimSuffix(trimmed, "&")))
+	}
+
+	cmdName, args, err := readLine([]rune(line))
+	if err != nil {
+		return nil, err
+	}
+
+	cmdName, args, err = s.expandAlias(cmdName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	name, cmd, err := s.lookupCommand(cmdName)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.GameOver {
+		return nil, fmt.Errorf("game over: press Esc to quit")
+	}
+
+	if s.background {
+		if interactiveCommands[name] {
+			return nil, fmt.Errorf("%s can't be run in the background", cmdName)
+		}
+	} else {
+		s.auditCommand(cmdName, args)
+	}
+
+	output, err := cmd.Execute(s, args...)
+	if s.background || s.job {
+		return output, err
+	}
+	if err == nil {
+		s.traceNoise(name)
+	}
+	s.checkMissions()
+
+	return output, err
+}
+
+// expandAlias replaces cmdName with its alias from the current host's
+// aliases file. Aliases may refer to other aliases but not to themselves.
+func (s *State) expandAlias(cmdName string, args []string) (string, []string, error) {
+	aliases := s.aliases()
+	seen := map[string]bool{}
+	for {
+		value, ok := aliases[cmdName]
+		if !ok || seen[cmdName] {
+			return cmdName, args, nil
+		}
+		seen[cmdName] = true
+
+		aliasCmd, aliasArgs, err := readLine([]rune(value))
+		if err != nil {
+			return "", nil, fmt.Errorf("alias %s: %v", cmdName, err)
+		}
+
+		cmdName = aliasCmd
+		args = append(aliasArgs, args...)
+	}
+}
+
+// aliases reads alias definitions from the current host's home directory.
+// Each line is of the form name=command, optionally prefixed with "alias".
+func (s *State) aliases() map[string]string {
+	aliases := map[string]string{}
+	file, ok := s.HomeDir().Files[aliasesFileName].(*fs.Text)
+	if !ok {
+		return aliases
+	}
+
+	for _, line := range configLines(file.Contents) {
+		line = strings.TrimPrefix(line, "alias ")
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		name := strings.TrimSpace(parts[0])
+		value := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+		if name != "" && value != "" {
+			aliases[name] = value
+		}
+	}
+
+	return aliases
+}
+
+// runShellRC runs each line of the current host's shell startup file and
+// returns the combined output. Startup files cannot trigger further startup
+// files, so a connect inside one does not recurse.
+func (s *State) runShellRC() []byte {
+	if s.inShellRC {
+		return nil
+	}
+
+	file, ok := s.HomeDir().Files[shellRCFileName].(*fs.Text)
+	if !ok {
+		return nil
+	}
+
+	s.inShellRC = true
+	defer func() { s.inShellRC = false }()
+
+	buf := &bytes.Buffer{}
+	for _, line := range configLines(file.Contents) {
+		output, err := s.Run(line)
+		if err != nil {
+			buf.WriteString(fmt.Sprintf("%s: %v\n", shellRCFileName, err))
+		} else if len(output) > 0 {
+			buf.Write(output)
+			buf.WriteRune('\n')
+		}
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// configLines returns the non-empty, non-comment lines of a config file
+func configLines(contents []byte) []string {
+	lines := []string{}
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+var AliasCommand = &Command{
+	ShortHelp: "List or define command aliases for the current host",
+	LongHelp: `List or define command aliases for the current host. Aliases are stored in ~/.aliases
+as lines of the form name=command and are expanded when a command is run.
+Usage: alias [name] ["command"]`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) == 1 || len(args) > 2 {
+			return nil, fmt.Errorf("must supply zero arguments, or a name and a command")
+		}
+
+		if len(args) == 0 {
+			aliases := state.aliases()
+			if len(aliases) == 0 {
+				return []byte("No aliases defined."), nil
+			}
+
+			lines := []string{}
+			for name, value := range aliases {
+				lines = append(lines, fmt.Sprintf("%s=\"%s\"", name, value))
+			}
+			sort.Strings(lines)
+			return []byte(strings.Join(lines, "\n")), nil
+		}
+
+		if args[0] == "" || strings.ContainsAny(args[0], " =\"'\\") {
+			return nil, fmt.Errorf("invalid alias name")
+		}
+
+		home := state.HomeDir()
+		now := state.Clock.Now()
+		file, ok := home.Files[aliasesFileName].(*fs.Text)
+		if !ok {
+			if _, exists := home.Files[aliasesFileName]; exists {
+				return nil, fmt.Errorf("%s is not a writeable file", aliasesFileName)
+			}
+			file = fs.NewText(aliasesFileName, nil, now)
+			if err := state.addFile(home, file); err != nil {
+				return nil, err
+			}
+		}
+		if err := state.reserve(home, int64(len(args[0])+len(args[1])+5)); err != nil {
+			return nil, err
+		}
+
+		if len(file.Contents) > 0 && !bytes.HasSuffix(file.Contents, []byte("\n")) {
+			file.Contents = append(file.Contents, '\n')
+		}
+		file.Contents = append(file.Contents, []byte(fmt.Sprintf("%s=\"%s\"\n", args[0], args[1]))...)
+		file.Modified = now
+
+		return nil, nil
+	},
+}
@@ -2,38 +2,111 @@ package command
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/ckiely91/shellsim/clock"
 	"github.com/ckiely91/shellsim/fs"
 )
 
 type State struct {
 	CurrentDir     *fs.Directory
+	PreviousDir    *fs.Directory
 	LocalHost      *Host
+	LocalUser      *User
 	CurrentHost    *Host
+	CurrentUser    *User
 	Commands       map[string]*Command
+	Programs       map[string]*Command
 	CommandHistory *CommandHistory
 	EventChan      chan *Event
+	AutoPage       bool
+	Clock          *clock.Clock
+	Network        *Network
+	Discovered     map[*Host]*Host
+	Chain          []*Host
+	ShowMapPane    bool
+	Missions       []*Mission
+	Trace          *Trace
+	Traces         int
+	GameOver       bool
+	Crack          *Crack
+
+	inShellRC bool
+	// background is set for sessions running cron jobs
+	background bool
+	// job is set for copies of the session running the player's jobs
+	job bool
+	// jobs is shared by every copy of the player's session
+	jobs *jobTable
 }
 
 func NewState() *State {
-	localHost := NewHost("192.168.1.1")
+	clk := clock.New(clock.Epoch)
+	network, localHost := defaultWorld(clk.Now())
 
-	otherHost1 := NewHost("200.12.1.29")
-	otherHost2 := NewHost("129.21.230.12")
+	return NewStateWithNetwork(clk, network, localHost)
+}
 
-	localHost.ConnectedHosts[otherHost1.Hostname] = otherHost1
-	localHost.ConnectedHosts[otherHost2.Hostname] = otherHost2
+// NewStateWithNetwork starts a session as root on localHost, which must be
+// part of network.
+func NewStateWithNetwork(clk *clock.Clock, network *Network, localHost *Host) *State {
+	localUser := localHost.Users["root"]
 
-	return &State{
-		CurrentDir:     localHost.RootDir,
+	s := &State{
+		CurrentDir:     localHost.HomeDir(localUser),
 		LocalHost:      localHost,
+		LocalUser:      localUser,
 		CurrentHost:    localHost,
+		CurrentUser:    localUser,
 		Commands:       standardCommands(),
+		Programs:       standardPrograms(),
 		CommandHistory: &CommandHistory{},
 		EventChan:      make(chan *Event),
+		Clock:          clk,
+		Network:        network,
+		Discovered:     map[*Host]*Host{localHost: nil},
+		jobs:           &jobTable{},
 	}
+	s.startCron()
+
+	return s
 }
 
 func (s *State) Logf(line string, args ...interface{}) {
 	go sendEvent(s.EventChan, EventTypeLog, fmt.Sprintf(line, args...))
 }
+
+// logLines sends several lines to the screen, keeping them in order
+func (s *State) logLines(lines ...string) {
+	go func() {
+		for _, line := range lines {
+			sendEvent(s.EventChan, EventTypeLog, line)
+		}
+	}()
+}
+
+// StatusLine describes the active trace and password attack, if any, for the
+// status bar
+func (s *State) StatusLine() string {
+	if s.GameOver {
+		return "GAME OVER"
+	}
+
+	parts := []string{}
+	if s.Trace != nil {
+		parts = append(parts, s.Trace.status())
+	}
+	if s.Crack != nil {
+		parts = append(parts, s.Crack.status())
+	}
+	return strings.Join(parts, " | ")
+}
+
+// Prompt shows where the player is, and how much unread mail is waiting
+func (s *State) Prompt() string {
+	prompt := fmt.Sprintf("%v:%v", s.CurrentHost.Hostname, s.dirPath(s.CurrentDir))
+	if unread := s.unreadMail(); unread > 0 {
+		prompt += fmt.Sprintf(" [%d new mail]", unread)
+	}
+	return prompt
+}
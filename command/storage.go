@@ -0,0 +1,194 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+// rootDevice is the name df gives each host's root filesystem
+const rootDevice = "/dev/sda1"
+
+// Used is how much of the host's storage its files take up
+func (h *Host) Used() int64 {
+	return h.RootDir.DiskUsage()
+}
+
+// reserve checks there is room on the host for size more bytes
+func (h *Host) reserve(size int64) error {
+	return checkSpace(h.Capacity, h.Used(), size)
+}
+
+// checkSpace checks size more bytes fit on a disk with capacity, of which
+// used is taken. Negative sizes, for files that are shrinking, always fit.
+func checkSpace(capacity, used, size int64) error {
+	if capacity > 0 && size > 0 && used+size > capacity {
+		return fmt.Errorf("no space left on device")
+	}
+	return nil
+}
+
+// reserve checks there is room for size more bytes on whichever disk dir is
+// on, which may be mounted from elsewhere, and that the disk can be written.
+func (s *State) reserve(dir *fs.Directory, size int64) error {
+	if err := s.checkWritable(dir); err != nil {
+		return err
+	}
+	if m := s.CurrentHost.RootDir.MountOf(dir); m != nil {
+		capacity, used := s.mountUsage(m)
+		return checkSpace(capacity, used, size)
+	}
+	return s.CurrentHost.reserve(size)
+}
+
+// writeFile puts file at an absolute path on the host if there is room for
+// it, replacing anything already there.
+func (h *Host) writeFile(path string, file fs.File, now time.Time) error {
+	size := file.Size()
+	if existing := fs.FindFileRelativeNoFollow(h.RootDir, h.RootDir, path); existing != nil {
+		size -= existing.Size()
+	}
+	if err := h.reserve(size); err != nil {
+		return err
+	}
+	return fs.WriteFile(h.RootDir, path, file, now)
+}
+
+func formatSize(size int64, human bool) string {
+	if human {
+		return fs.HumanSize(size)
+	}
+	return fmt.Sprintf("%d", size)
+}
+
+var DFCommand = &Command{
+	ShortHelp: "Show how much storage the current host has free",
+	LongHelp: `Show the size of the current host's filesystem and everything mounted on it, how much of
+each is used and how much is free. Sizes are in bytes, or human readable with -h. When a disk
+is full, writing to it fails until space is freed by removing files.
+Usage: df [-h]`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		flags, args, err := parseFlags(args, "h")
+		if err != nil {
+			return nil, err
+		}
+		if len(args) != 0 {
+			return nil, fmt.Errorf("takes no arguments")
+		}
+
+		host := state.CurrentHost
+		rows := [][]string{
+			{"Filesystem", "Size", "Used", "Avail", "Use%", "Mounted on"},
+			dfRow(rootDevice, host.Capacity, host.Used(), "/", flags['h']),
+		}
+		for _, m := range host.RootDir.Mounts {
+			capacity, used := state.mountUsage(m)
+			rows = append(rows, dfRow(m.Device, capacity, used, state.dirPath(m.Root), flags['h']))
+		}
+		return formatTable(rows), nil
+	},
+}
+
+// dfRow describes a disk for df. Disks with no capacity have no limit.
+func dfRow(device string, capacity, used int64, mountedOn string, human bool) []string {
+	size, avail, percent := "-", "-", "-"
+	if capacity > 0 {
+		size = formatSize(capacity, human)
+		free := capacity - used
+		if free < 0 {
+			free = 0
+		}
+		avail = formatSize(free, human)
+		percent = fmt.Sprintf("%d%%", (used*100+capacity-1)/capacity)
+	}
+	return []string{device, size, formatSize(used, human), avail, percent, mountedOn}
+}
+
+// formatTable lines up rows of fields in columns, with the first column
+// aligned left and the rest right, except the last
+func formatTable(rows [][]string) []byte {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, field := range row {
+			if len(field) > widths[i] {
+				widths[i] = len(field)
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	for _, row := range rows {
+		fields := make([]string, len(row))
+		for i, field := range row {
+			switch i {
+			case 0:
+				fields[i] = fmt.Sprintf("%-*s", widths[i], field)
+			case len(row) - 1:
+				fields[i] = field
+			default:
+				fields[i] = fmt.Sprintf("%*s", widths[i], field)
+			}
+		}
+		buf.WriteString(strings.Join(fields, "  ") + "\n")
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// diskUsage lists the total size of dir and each directory beneath it,
+// deepest first, as du does
+func diskUsage(dir *fs.Directory, path string, human bool) []string {
+	names := []string{}
+	for _, file := range dir.Files {
+		if file.Type() == fs.FileTypeDirectory {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+
+	lines := []string{}
+	for _, name := range names {
+		sub := dir.Files[strings.ToLower(name)].(*fs.Directory)
+		lines = append(lines, diskUsage(sub, strings.TrimSuffix(path, "/")+"/"+name, human)...)
+	}
+	return append(lines, fmt.Sprintf("%s\t%s", formatSize(dir.DiskUsage(), human), path))
+}
+
+var DUCommand = &Command{
+	ShortHelp: "Show how much storage files and directories take up",
+	LongHelp: `Show how much storage a directory and each directory beneath it take up, or a single file.
+Without a path, the current directory is used. Sizes are in bytes, or human readable with -h.
+With -s only the total is shown.
+Usage: du [-hs] [path]`,
+	TabCompletionTypes: []TabCompletionType{TabCompletionTypeFile},
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		flags, paths, err := parseFlags(args, "hs")
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) > 1 {
+			return nil, fmt.Errorf("must supply zero or one paths")
+		}
+
+		path := "."
+		if len(paths) == 1 {
+			path = paths[0]
+		}
+		file := state.FindFile(path)
+		if file == nil {
+			return nil, fmt.Errorf("file or directory not found")
+		}
+
+		dir, ok := file.(*fs.Directory)
+		if !ok {
+			return []byte(fmt.Sprintf("%s\t%s", formatSize(file.Size(), flags['h']), path)), nil
+		}
+		if flags['s'] {
+			return []byte(fmt.Sprintf("%s\t%s", formatSize(dir.DiskUsage(), flags['h']), path)), nil
+		}
+		return []byte(strings.Join(diskUsage(dir, path, flags['h']), "\n")), nil
+	},
+}
@@ -13,28 +13,33 @@ func tabCompletion(state *State, currentLine []rune) []rune {
 	}
 
 	candidates := []string{}
+	commands := state.availableCommands()
 
 	if len(args) == 0 {
 		// Check if we can autocomplete the command
-		for cmdName := range state.Commands {
+		for cmdName := range commands {
 			if strings.Index(cmdName, cmd) == 0 {
 				candidates = append(candidates, cmdName)
 			}
 		}
-	} else if theCmd, ok := state.Commands[cmd]; ok {
+	} else if theCmd, ok := commands[cmd]; ok {
 		arg := args[len(args)-1]
 		for _, t := range theCmd.TabCompletionTypes {
 			switch t {
 			case TabCompletionTypeFile:
 				for fileName := range state.CurrentDir.Files {
+					// Only offer hidden files once the user has typed the dot
+					if isHidden(fileName) && !isHidden(arg) {
+						continue
+					}
 					if strings.Index(fileName, arg) == 0 {
 						candidates = append(candidates, combineArgs(cmd, append(args[:len(args)-1], fileName)...))
 					}
 				}
 			case TabCompletionTypeServer:
-				for host := range state.CurrentHost.ConnectedHosts {
-					if strings.Index(host, arg) == 0 {
-						candidates = append(candidates, combineArgs(cmd, append(args[:len(args)-1], host)...))
+				for _, name := range state.knownNames() {
+					if strings.Index(name, arg) == 0 {
+						candidates = append(candidates, combineArgs(cmd, append(args[:len(args)-1], name)...))
 					}
 				}
 			}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/ckiely91/shellsim/clock"
+)
+
+var DateCommand = &Command{
+	ShortHelp: "Show the current date and time",
+	LongHelp: `Show the current date and time in the simulation.
+Usage: date`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("takes no arguments")
+		}
+
+		return []byte(state.Clock.Now().Format(time.UnixDate)), nil
+	},
+}
+
+var ClockCommand = &Command{
+	ShortHelp: "Pause, resume or speed up the simulation clock",
+	LongHelp: `Show the state of the simulation clock, pause or resume it, or change how many simulated
+seconds pass for each real one, up to 3600. Scans, traces, mail and timed missions all follow the clock.
+Usage: clock [pause|resume|speed n]`,
+	Execute: func(state *State, args ...string) ([]byte, error) {
+		if len(args) == 0 {
+			status := "running"
+			if state.Clock.Paused() {
+				status = "paused"
+			}
+			return []byte(fmt.Sprintf("%s, %s at %gx speed", state.Clock.Now().Format(time.UnixDate), status, state.Clock.Speed())), nil
+		}
+
+		switch args[0] {
+		case "pause":
+			state.Clock.Pause()
+			return []byte("Clock paused."), nil
+		case "resume":
+			state.Clock.Resume()
+			return []byte("Clock resumed."), nil
+		case "speed":
+			if len(args) != 2 {
+				return nil, fmt.Errorf("must supply a speed")
+			}
+			speed, err := strconv.ParseFloat(args[1], 64)
+			if err != nil || math.IsNaN(speed) || math.IsInf(speed, 0) || speed <= 0 || speed > clock.MaxSpeed {
+				return nil, fmt.Errorf("speed must be a number above 0 and at most %d", clock.MaxSpeed)
+			}
+			state.Clock.SetSpeed(speed)
+			return []byte(fmt.Sprintf("Clock running at %gx speed.", speed)), nil
+		}
+
+		return nil, fmt.Errorf("unknown clock command: %s", args[0])
+	},
+}
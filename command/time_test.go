@@ -0,0 +1,51 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ckiely91/shellsim/clock"
+)
+
+func TestClockSpeed(t *testing.T) {
+	clk := clock.NewFake(clock.Epoch)
+	network, localHost := defaultWorld(clk.Now())
+	state := NewStateWithNetwork(clk, network, localHost)
+
+	for _, speed := range []string{"0", "-2", "NaN", "Inf", "-Inf", "1e300", "3601", "fast"} {
+		if _, err := ClockCommand.Execute(state, "speed", speed); err == nil {
+			t.Errorf("clock speed %s was accepted", speed)
+		}
+		if got := clk.Speed(); got != 1 {
+			t.Fatalf("clock speed %s changed the speed to %g", speed, got)
+		}
+	}
+
+	if _, err := ClockCommand.Execute(state, "speed", "60"); err != nil {
+		t.Fatalf("clock speed 60: %v", err)
+	}
+	if got := clk.Speed(); got != 60 {
+		t.Fatalf("speed is %g, want 60", got)
+	}
+}
+
+func TestFakeClockDeliversMail(t *testing.T) {
+	clk := clock.NewFake(clock.Epoch)
+	network, localHost := defaultWorld(clk.Now())
+	state := NewStateWithNetwork(clk, network, localHost)
+
+	before := len(state.mailbox(""))
+	state.sendMail(&Message{From: "ops", Subject: "later", Body: "hello", Delay: 90})
+
+	// Nothing is running the event loop, so this would block if delivery
+	// went through it
+	clk.Advance(89 * time.Second)
+	if got := len(state.mailbox("")); got != before {
+		t.Fatalf("%d messages before the delay, want %d", got, before)
+	}
+	clk.Advance(time.Second)
+	inbox := state.mailbox("")
+	if len(inbox) != before+1 || inbox[len(inbox)-1].subject != "later" {
+		t.Fatalf("mail wasn't delivered after its delay")
+	}
+}
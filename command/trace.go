@@ -0,0 +1,163 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// traceBaseTime is how long a connection to a host with intrusion
+	// detection level 1 lasts before it is traced. Higher levels divide it.
+	traceBaseTime = 180 * time.Second
+	// traceTick is how often an active trace is advanced
+	traceTick = time.Second
+	// maxTraces is the number of completed traces that ends the game
+	maxTraces = 3
+	// traceBarWidth is the number of cells in the status bar's trace meter
+	traceBarWidth = 20
+)
+
+// noisyCommands are the commands that draw attention while the player is
+// connected to a watched host, with how far each one pushes the trace along
+// at intrusion detection level 1.
+var noisyCommands = map[string]time.Duration{
+	"portscan": 15 * time.Second,
+	"scan":     10 * time.Second,
+	"scp":      5 * time.Second,
+	"append":   2 * time.Second,
+	"replace":  2 * time.Second,
+	"mkdir":    2 * time.Second,
+	"rmdir":    2 * time.Second,
+	"rm":       2 * time.Second,
+	"crontab":  5 * time.Second,
+	"kill":     5 * time.Second,
+	"ln":       2 * time.Second,
+}
+
+// Trace is an intrusion detection system working back along the player's
+// connection. Once Progress reaches Limit the player is disconnected.
+type Trace struct {
+	Host     *Host
+	Progress time.Duration
+	Limit    time.Duration
+
+	// last is the simulated time the trace was last advanced
+	last time.Time
+}
+
+// traceLimit is how long the host takes to trace a connection, or zero if it
+// doesn't run intrusion detection.
+func (h *Host) traceLimit() time.Duration {
+	if h.IDSLevel <= 0 {
+		return 0
+	}
+	return traceBaseTime / time.Duration(h.IDSLevel)
+}
+
+// watchfulHost is the host in the connection chain with the highest
+// intrusion detection level, or nil if none of them are watching.
+func (s *State) watchfulHost() *Host {
+	var watchful *Host
+	for _, host := range s.Chain {
+		if host.IDSLevel > 0 && (watchful == nil || host.IDSLevel > watchful.IDSLevel) {
+			watchful = host
+		}
+	}
+	return watchful
+}
+
+// updateTrace starts, stops or retargets the trace after the connection
+// chain has changed. Progress carries over while the player stays connected.
+func (s *State) updateTrace() {
+	host := s.watchfulHost()
+	if host == nil {
+		s.Trace = nil
+		return
+	}
+
+	if s.Trace != nil {
+		s.Trace.Host = host
+		s.Trace.Limit = host.traceLimit()
+		s.checkTrace()
+		return
+	}
+
+	s.Trace = &Trace{Host: host, Limit: host.traceLimit(), last: s.Clock.Now()}
+	s.Logf("Warning: %s is running intrusion detection. Trace started.", host.Hostname)
+	s.scheduleTraceTick(s.Trace)
+}
+
+func (s *State) scheduleTraceTick(trace *Trace) {
+	s.after(traceTick, func() {
+		// A newer trace or a disconnect replaces this one
+		if s.Trace != trace {
+			return
+		}
+
+		now := s.Clock.Now()
+		trace.Progress += now.Sub(trace.last)
+		trace.last = now
+		if s.checkTrace() {
+			s.scheduleTraceTick(trace)
+		}
+	})
+}
+
+// traceNoise pushes an active trace along after a noisy command
+func (s *State) traceNoise(cmdName string) {
+	noise, ok := noisyCommands[cmdName]
+	if !ok || s.Trace == nil {
+		return
+	}
+
+	s.Trace.Progress += noise * time.Duration(s.Trace.Host.IDSLevel)
+	s.checkTrace()
+}
+
+// checkTrace disconnects the player if the trace has completed, reporting
+// whether it is still running.
+func (s *State) checkTrace() bool {
+	if s.Trace == nil {
+		return false
+	}
+	if s.Trace.Progress < s.Trace.Limit {
+		return true
+	}
+
+	host := s.Trace.Host
+	s.Trace = nil
+	s.Login(s.LocalHost, s.LocalUser)
+	s.Chain = nil
+	s.Traces++
+
+	lines := []string{fmt.Sprintf("Trace complete: %s has found you. Connection terminated.", host.Hostname)}
+	if s.Traces >= maxTraces {
+		s.GameOver = true
+		lines = append(lines, "You have been traced too many times. GAME OVER. Press Esc to quit.")
+	} else if left := maxTraces - s.Traces; left == 1 {
+		lines = append(lines, "One more trace and it's over.")
+	} else {
+		lines = append(lines, fmt.Sprintf("%d more traces and it's over.", left))
+	}
+	s.logLines(lines...)
+
+	return false
+}
+
+// status shows how close the trace is to completing
+func (t *Trace) status() string {
+	filled := int(int64(traceBarWidth) * int64(t.Progress) / int64(t.Limit))
+	if filled > traceBarWidth {
+		filled = traceBarWidth
+	}
+	remaining := (t.Limit - t.Progress).Round(time.Second)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return fmt.Sprintf("TRACE %s [%s%s] %s remaining",
+		t.Host.Hostname,
+		strings.Repeat("#", filled), strings.Repeat("-", traceBarWidth-filled),
+		remaining)
+}
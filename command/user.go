@@ -0,0 +1,56 @@
+package command
+
+import (
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+type User struct {
+	Name     string
+	Home     string
+	Password string
+}
+
+// AddUser creates an account on the host along with its home directory
+func (h *Host) AddUser(name, home string, now time.Time) (*User, error) {
+	if _, err := fs.MkdirAll(h.RootDir, home, now); err != nil {
+		return nil, err
+	}
+
+	user := &User{Name: name, Home: home}
+	h.Users[name] = user
+	return user, nil
+}
+
+// HomeDir returns the user's home directory on the host, falling back to the
+// root directory if it has since been removed.
+func (h *Host) HomeDir(user *User) *fs.Directory {
+	if user != nil {
+		if dir, ok := fs.FindFileRelative(h.RootDir, h.RootDir, user.Home).(*fs.Directory); ok {
+			return dir
+		}
+	}
+	return h.RootDir
+}
+
+func (s *State) HomeDir() *fs.Directory {
+	return s.CurrentHost.HomeDir(s.CurrentUser)
+}
+
+// ChangeDir moves to dir, remembering the previous directory for cd -
+func (s *State) ChangeDir(dir *fs.Directory) {
+	if dir != s.CurrentDir {
+		s.PreviousDir = s.CurrentDir
+	}
+	s.CurrentDir = dir
+}
+
+// Login switches the session to another host as the given user, starting in
+// their home directory.
+func (s *State) Login(host *Host, user *User) {
+	s.CurrentHost = host
+	s.CurrentUser = user
+	s.CurrentDir = host.HomeDir(user)
+	s.PreviousDir = nil
+}
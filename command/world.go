@@ -0,0 +1,137 @@
+package command
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+// defaultWorld builds the small built-in network: the player's machine on a
+// home LAN behind a router that links it to two remote networks.
+func defaultWorld(now time.Time) (*Network, *Host) {
+	network := NewNetwork()
+
+	lan := network.AddSubnet(mustParseCIDR("192.168.1.0/24"), 1*time.Millisecond)
+	remote1 := network.AddSubnet(mustParseCIDR("200.12.1.0/24"), 18*time.Millisecond)
+	remote2 := network.AddSubnet(mustParseCIDR("129.21.230.0/24"), 31*time.Millisecond)
+
+	localHost := NewHost("192.168.1.1", now)
+	localHost.AddInterface("eth0", net.ParseIP("192.168.1.1"), lan)
+	localHost.AddRoute(mustParseCIDR("0.0.0.0/0"), net.ParseIP("192.168.1.254"))
+	localHost.AddService(22, ServiceTypeSSH, "OpenSSH 7.4", "")
+
+	router := NewHost("192.168.1.254", now)
+	router.Forwarding = true
+	router.RestrictCommands("show", "ping", "traceroute")
+	router.AddInterface("eth0", net.ParseIP("192.168.1.254"), lan)
+	router.AddInterface("eth1", net.ParseIP("200.12.1.1"), remote1)
+	router.AddInterface("eth2", net.ParseIP("129.21.230.1"), remote2)
+	router.AddService(22, ServiceTypeSSH, "Dropbear 2017.75", "SSH-2.0-dropbear_2017.75")
+	router.AddService(53, ServiceTypeDNS, "dnsmasq 2.78", "")
+	router.Users["root"].Password = "admin"
+	router.Capacity = 64 * 1024
+	router.AddDNSRecord("www.globex.com", net.ParseIP("200.12.1.29"))
+	router.AddDNSRecord("globex.com", net.ParseIP("200.12.1.29"))
+	router.AddDNSRecord("ftp.initech.net", net.ParseIP("129.21.230.12"))
+
+	otherHost1 := NewHost("200.12.1.29", now)
+	otherHost1.AddInterface("eth0", net.ParseIP("200.12.1.29"), remote1)
+	otherHost1.DomainName = "www.globex.com"
+	otherHost1.SetIDSLevel(1)
+	otherHost1.AddRoute(mustParseCIDR("0.0.0.0/0"), net.ParseIP("200.12.1.1"))
+	otherHost1.AddService(22, ServiceTypeSSH, "OpenSSH 6.6", "SSH-2.0-OpenSSH_6.6 Ubuntu")
+	otherHost1.AddService(80, ServiceTypeHTTP, "Apache 2.4.7", "Apache/2.4.7 (Ubuntu)")
+	otherHost1.Users["root"].Password = "letmein"
+	otherHost1.WriteFile("/var/log/apache2/access.log", accessLog(now, 6000), now)
+	otherHost1.Capacity = 512 * 1024
+	otherHost1.WriteFile("/root/todo.txt", "- renew ssl cert\n- ask initech how their backup tool stores its key\n", now)
+
+	otherHost2 := NewHost("129.21.230.12", now)
+	otherHost2.AddInterface("eth0", net.ParseIP("129.21.230.12"), remote2)
+	otherHost2.DomainName = "ftp.initech.net"
+	otherHost2.SetIDSLevel(2)
+	otherHost2.WriteFile(crontabPath, "# m h dom mon dow user command\n0 * * * * root rm /var/log/audit.log\n", now)
+	otherHost2.AddRoute(mustParseCIDR("0.0.0.0/0"), net.ParseIP("129.21.230.1"))
+	otherHost2.AddService(21, ServiceTypeFTP, "vsftpd 2.3.4", "220 (vsFTPd 2.3.4)")
+	otherHost2.AddService(2222, ServiceTypeSSH, "Dropbear 2014.63", "SSH-2.0-dropbear_2014.63")
+	otherHost2.AddService(3306, ServiceTypeDB, "MySQL 5.5.62", "")
+	otherHost2.AddService(2049, ServiceTypeNFS, "nfs-kernel-server 1.2.8", "")
+	otherHost2.Firewall.AddRule(FirewallDeny, "*", 3306)
+	otherHost2.WriteFile(exportsPath, "/srv/ftp *(rw,no_root_squash)\n", now)
+	otherHost2.WriteFile("/srv/ftp/pub/readme.txt", "Initech public file drop. Don't leave anything sensitive here.\n", now)
+	otherHost2.PasswordStrength = PasswordMedium
+	otherHost2.DisableCommands("replace", "ln")
+	otherHost2.Users["root"].Password = "dragon42"
+	otherHost2.Capacity = 2 * 1024 * 1024
+
+	// A backup with a secret inside, for those who find the key
+	backup := fs.NewDirectory(nil, "", now)
+	fs.WriteFile(backup, "/payroll.csv", fs.NewEncrypted("payroll.csv", []byte("name,salary\nbill lumbergh,185000\nmilton waddams,0\n"), "initech1987", now), now)
+	fs.WriteFile(backup, "/notes/readme.txt", fs.NewText("readme.txt", []byte("Nightly backup. Sensitive files are encrypted.\n"), now), now)
+	fs.WriteFile(otherHost2.RootDir, "/var/backups/backup.zip", fs.NewArchive("backup.zip", backup, now), now)
+
+	localHost.Capacity = 64 * 1024 * 1024
+	usb := localHost.AddDevice("/dev/sdb1", 8*1024*1024, now)
+	fs.WriteFile(usb.Root, "/dead-drop.txt", fs.NewText("dead-drop.txt", []byte("Initech shares /srv/ftp with anyone who asks. mount it.\n"), now), now)
+	for _, dir := range []string{"/mnt/usb", "/mnt/share"} {
+		fs.MkdirAll(localHost.RootDir, dir, now)
+	}
+	localHost.WriteFile(hostsFilePath, "192.168.1.1 homebox\n192.168.1.254 router gateway\n", now)
+	localHost.WriteFile(DefaultWordlistPath, commonPasswords, now)
+	localHost.WriteFile(environmentPath, "PATH="+defaultPath+"\n", now)
+	for _, program := range []string{"portscan", "crack"} {
+		localHost.InstallProgram("/usr/bin", program, now)
+	}
+	for _, host := range []*Host{localHost, router, otherHost1, otherHost2} {
+		host.WriteFile(resolvConfFilePath, "nameserver 192.168.1.254\n", now)
+		network.AddHost(host)
+	}
+
+	return network, localHost
+}
+
+// commonPasswords is the wordlist the player starts with
+const commonPasswords = `123456
+password
+12345678
+qwerty
+abc123
+letmein
+monkey
+dragon
+sunshine
+princess
+football
+iloveyou
+admin
+welcome
+master
+shadow
+trustno1
+baseball
+superman
+michael
+`
+
+// accessLog makes up a web server log of lines requests leading up to now
+func accessLog(now time.Time, lines int) string {
+	pages := []string{"/", "/index.html", "/about.html", "/products.html", "/login.php", "/favicon.ico"}
+	buf := &strings.Builder{}
+	for i := 0; i < lines; i++ {
+		when := now.Add(-time.Duration(lines-i) * 7 * time.Second)
+		fmt.Fprintf(buf, "10.%d.%d.%d - - [%s] \"GET %s HTTP/1.1\" 200 %d\n",
+			i%7, i*13%256, i*29%254+1, when.Format("02/Jan/2006:15:04:05 -0700"), pages[i*7%len(pages)], 512+i*31%4096)
+	}
+	return buf.String()
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, cidr, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return cidr
+}
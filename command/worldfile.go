@@ -0,0 +1,60 @@
+package command
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/fs"
+)
+
+// WorldFile is a file that data files can put on a host. Its contents are
+// given as text, or as base64 for binary files. Files with a key are
+// encrypted with it.
+type WorldFile struct {
+	Host     string `json:"host"`
+	Path     string `json:"path"`
+	Contents string `json:"contents,omitempty"`
+	Base64   string `json:"base64,omitempty"`
+	Key      string `json:"key,omitempty"`
+}
+
+// file builds the file to be put in place
+func (f *WorldFile) file(now time.Time) (fs.File, error) {
+	if !strings.HasPrefix(f.Path, "/") {
+		return nil, fmt.Errorf("path must be absolute")
+	}
+	name := f.Path[strings.LastIndex(f.Path, "/")+1:]
+
+	if f.Base64 == "" {
+		if f.Key != "" {
+			return fs.NewEncrypted(name, []byte(f.Contents), f.Key, now), nil
+		}
+		return fs.NewText(name, []byte(f.Contents), now), nil
+	}
+
+	if f.Contents != "" || f.Key != "" {
+		return nil, fmt.Errorf("binary files can't have text contents or a key")
+	}
+	data, err := base64.StdEncoding.DecodeString(f.Base64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %v", err)
+	}
+	return fs.NewBinary(name, data, now), nil
+}
+
+// place puts the file on its host, replacing anything already there
+func (f *WorldFile) place(s *State) error {
+	host := s.missionHost(f.Host)
+	if host == nil {
+		return fmt.Errorf("no host %s for %s", f.Host, f.Path)
+	}
+
+	now := s.Clock.Now()
+	file, err := f.file(now)
+	if err != nil {
+		return err
+	}
+	return fs.WriteFile(host.RootDir, f.Path, file, now)
+}
@@ -0,0 +1,39 @@
+package fs
+
+import "time"
+
+// Archive is a file bundling up a tree of other files, which must be
+// extracted before they can be used.
+type Archive struct {
+	FileName string
+	// Root holds the archived files. It has no parent.
+	Root     *Directory
+	Created  time.Time
+	Modified time.Time
+}
+
+func NewArchive(name string, root *Directory, now time.Time) *Archive {
+	return &Archive{
+		FileName: name,
+		Root:     root,
+		Created:  now,
+		Modified: now,
+	}
+}
+
+func (a *Archive) Type() FileType {
+	return FileTypeArchive
+}
+
+func (a *Archive) Name() string {
+	return a.FileName
+}
+
+// Size is that of the archived files, which compress to about half
+func (a *Archive) Size() int64 {
+	return a.Root.Size()/2 + 1
+}
+
+func (a *Archive) ModTime() time.Time {
+	return a.Modified
+}
@@ -0,0 +1,37 @@
+package fs
+
+import "time"
+
+// Binary is a file of arbitrary bytes, such as a compiled program or an
+// image, which can't be read as text.
+type Binary struct {
+	FileName string
+	Data     []byte
+	Created  time.Time
+	Modified time.Time
+}
+
+func NewBinary(name string, data []byte, now time.Time) *Binary {
+	return &Binary{
+		FileName: name,
+		Data:     data,
+		Created:  now,
+		Modified: now,
+	}
+}
+
+func (b *Binary) Type() FileType {
+	return FileTypeBinary
+}
+
+func (b *Binary) Name() string {
+	return b.FileName
+}
+
+func (b *Binary) Size() int64 {
+	return int64(len(b.Data))
+}
+
+func (b *Binary) ModTime() time.Time {
+	return b.Modified
+}
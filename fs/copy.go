@@ -0,0 +1,67 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Copy returns a copy of file called name, sharing nothing with the
+// original. Directories are copied with everything beneath them, and are
+// returned without a parent.
+func Copy(file File, name string, now time.Time) (File, error) {
+	switch f := file.(type) {
+	case *Text:
+		return NewText(name, append([]byte{}, f.Contents...), now), nil
+	case *Binary:
+		return NewBinary(name, append([]byte{}, f.Data...), now), nil
+	case *Executable:
+		return NewExecutable(name, f.Program, f.Length, now), nil
+	case *Symlink:
+		return NewSymlink(name, f.Target, now), nil
+	case *Encrypted:
+		return NewEncrypted(name, append([]byte{}, f.Plaintext...), f.Key, now), nil
+	case *Archive:
+		root, err := Copy(f.Root, "", now)
+		if err != nil {
+			return nil, err
+		}
+		return NewArchive(name, root.(*Directory), now), nil
+	case *Directory:
+		dir := NewDirectory(nil, name, now)
+		for key, child := range f.Files {
+			copied, err := Copy(child, child.Name(), now)
+			if err != nil {
+				return nil, err
+			}
+			if childDir, ok := copied.(*Directory); ok {
+				childDir.Parent = dir
+			}
+			dir.Files[key] = copied
+		}
+		return dir, nil
+	}
+
+	return nil, fmt.Errorf("%s cannot be copied", file.Name())
+}
+
+// WriteFile puts file at an absolute path beneath root, creating any
+// missing directories and replacing anything already there.
+func WriteFile(root *Directory, path string, file File, now time.Time) error {
+	idx := strings.LastIndex(path, "/")
+	dir, err := MkdirAll(root, path[:idx+1], now)
+	if err != nil {
+		return err
+	}
+
+	if err := ValidateFileName(file.Name()); err != nil {
+		return err
+	}
+	if d, ok := file.(*Directory); ok {
+		d.Parent = dir
+	}
+
+	dir.Files[strings.ToLower(file.Name())] = file
+	dir.Modified = now
+	return nil
+}
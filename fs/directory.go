@@ -4,12 +4,27 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 type Directory struct {
-	Parent  *Directory
-	DirName string
-	Files   map[string]File
+	Parent   *Directory
+	DirName  string
+	Files    map[string]File
+	Created  time.Time
+	Modified time.Time
+	// Mounts are the trees mounted in the filesystem, if this is its root
+	Mounts []*Mount
+}
+
+func NewDirectory(parent *Directory, name string, now time.Time) *Directory {
+	return &Directory{
+		Parent:   parent,
+		DirName:  name,
+		Files:    map[string]File{},
+		Created:  now,
+		Modified: now,
+	}
 }
 
 func (d *Directory) Type() FileType {
@@ -20,6 +35,43 @@ func (d *Directory) Name() string {
 	return d.DirName
 }
 
+// Size is the total size of all files beneath the directory
+func (d *Directory) Size() int64 {
+	var size int64
+	for _, f := range d.Files {
+		size += f.Size()
+	}
+	return size
+}
+
+// DiskUsage is the space the files beneath the directory take up on disk.
+// Unlike Size, text files hard linked together are only counted once.
+func (d *Directory) DiskUsage() int64 {
+	return d.diskUsage(map[*Inode]bool{})
+}
+
+func (d *Directory) diskUsage(seen map[*Inode]bool) int64 {
+	var size int64
+	for _, f := range d.Files {
+		switch f := f.(type) {
+		case *Directory:
+			size += f.diskUsage(seen)
+		case *Text:
+			if !seen[f.Inode] {
+				seen[f.Inode] = true
+				size += f.Size()
+			}
+		default:
+			size += f.Size()
+		}
+	}
+	return size
+}
+
+func (d *Directory) ModTime() time.Time {
+	return d.Modified
+}
+
 func (d *Directory) FullPath() string {
 	dirNames := []string{}
 	currentDir := d
@@ -42,3 +94,35 @@ func ValidateDirName(name string) error {
 
 	return fmt.Errorf("directory name contains invalid characters")
 }
+
+// MkdirAll finds or creates each directory along an absolute path beneath
+// root, returning the final directory.
+func MkdirAll(root *Directory, path string, now time.Time) (*Directory, error) {
+	dir := root
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		if name == "" {
+			continue
+		}
+
+		nameLower := strings.ToLower(name)
+		if file, ok := dir.Files[nameLower]; ok {
+			next, ok := file.(*Directory)
+			if !ok {
+				return nil, fmt.Errorf("%s is not a directory", name)
+			}
+			dir = next
+			continue
+		}
+
+		if err := ValidateDirName(name); err != nil {
+			return nil, err
+		}
+
+		next := NewDirectory(dir, name, now)
+		dir.Files[nameLower] = next
+		dir.Modified = now
+		dir = next
+	}
+
+	return dir, nil
+}
@@ -0,0 +1,78 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Encrypted is a text file that can only be read with its key. Until then
+// its contents show as ciphertext.
+type Encrypted struct {
+	FileName  string
+	Key       string
+	Plaintext []byte
+	Created   time.Time
+	Modified  time.Time
+}
+
+func NewEncrypted(name string, contents []byte, key string, now time.Time) *Encrypted {
+	return &Encrypted{
+		FileName:  name,
+		Key:       key,
+		Plaintext: contents,
+		Created:   now,
+		Modified:  now,
+	}
+}
+
+func (e *Encrypted) Type() FileType {
+	return FileTypeEncrypted
+}
+
+func (e *Encrypted) Name() string {
+	return e.FileName
+}
+
+func (e *Encrypted) Size() int64 {
+	return int64(len(e.Ciphertext()))
+}
+
+func (e *Encrypted) ModTime() time.Time {
+	return e.Modified
+}
+
+// Ciphertext is what the file looks like to anyone without the key: the
+// contents mixed with a keystream derived from the key, base64 encoded.
+func (e *Encrypted) Ciphertext() []byte {
+	stream := sha256.Sum256([]byte(e.Key))
+	mixed := make([]byte, len(e.Plaintext))
+	for i, b := range e.Plaintext {
+		if i%len(stream) == 0 && i > 0 {
+			stream = sha256.Sum256(stream[:])
+		}
+		mixed[i] = b ^ stream[i%len(stream)]
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(mixed)
+	lines := []string{"-----BEGIN ENCRYPTED MESSAGE-----"}
+	for len(encoded) > 64 {
+		lines = append(lines, encoded[:64])
+		encoded = encoded[64:]
+	}
+	if encoded != "" {
+		lines = append(lines, encoded)
+	}
+	lines = append(lines, "-----END ENCRYPTED MESSAGE-----")
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// Decrypt returns the contents if key is right
+func (e *Encrypted) Decrypt(key string) ([]byte, error) {
+	if key != e.Key {
+		return nil, fmt.Errorf("bad decrypt: wrong key")
+	}
+	return e.Plaintext, nil
+}
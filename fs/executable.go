@@ -0,0 +1,40 @@
+package fs
+
+import "time"
+
+// Executable is an installed program. Running it runs the program it names,
+// which must be one the game knows how to run.
+type Executable struct {
+	FileName string
+	Program  string
+	// Length is how big the program is on disk
+	Length   int64
+	Created  time.Time
+	Modified time.Time
+}
+
+func NewExecutable(name, program string, length int64, now time.Time) *Executable {
+	return &Executable{
+		FileName: name,
+		Program:  program,
+		Length:   length,
+		Created:  now,
+		Modified: now,
+	}
+}
+
+func (e *Executable) Type() FileType {
+	return FileTypeExecutable
+}
+
+func (e *Executable) Name() string {
+	return e.FileName
+}
+
+func (e *Executable) Size() int64 {
+	return e.Length
+}
+
+func (e *Executable) ModTime() time.Time {
+	return e.Modified
+}
@@ -1,7 +1,9 @@
 package fs
 
 import (
+	"fmt"
 	"strings"
+	"time"
 )
 
 type FileType uint8
@@ -9,72 +11,109 @@ type FileType uint8
 const (
 	FileTypeDirectory FileType = iota
 	FileTypeText
+	FileTypeSymlink
+	FileTypeExecutable
+	FileTypeEncrypted
+	FileTypeArchive
+	FileTypeBinary
 )
 
 type File interface {
 	Type() FileType
 	Name() string
+	Size() int64
+	ModTime() time.Time
 }
 
-func findFileRelativeToDir(dir *Directory, paths []string) File {
-	curPath := strings.ToLower(paths[0])
-	paths = paths[1:]
-	if len(paths) == 0 {
-		// We're looking for the actual file here
-		if curPath == ".." {
-			if dir.Parent == nil {
-				// Can't go further up. Return nothing
-				return nil
-			}
+// HumanSize formats a byte count using K, M and G suffixes
+func HumanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d", size)
+	}
 
-			return dir.Parent
+	value := float64(size)
+	for _, suffix := range []string{"K", "M", "G"} {
+		value /= unit
+		if value < unit || suffix == "G" {
+			if value < 10 {
+				return fmt.Sprintf("%.1f%s", value, suffix)
+			}
+			return fmt.Sprintf("%.0f%s", value, suffix)
 		}
+	}
 
-		if file, ok := dir.Files[curPath]; ok {
-			return file
-		}
+	return fmt.Sprintf("%d", size)
+}
 
+// findFile walks path from dir, following symbolic links along the way. The
+// final component is only followed if followLast is set. depth counts the
+// links already followed to reach this point.
+func findFile(dir *Directory, rootDir *Directory, path string, followLast bool, depth int) File {
+	if depth > MaxSymlinkDepth {
 		return nil
 	}
 
-	// Else we're looking for a directory to drill into
-	if curPath == ".." {
-		if dir.Parent == nil {
-			// Can't go further up. Return nothing
+	if strings.HasPrefix(path, "/") {
+		dir = rootDir
+	}
+	dir = enter(dir, rootDir)
+
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return dir
+	}
+
+	paths := strings.Split(path, "/")
+	var current File = dir
+	for i, curPath := range paths {
+		currentDir, ok := current.(*Directory)
+		if !ok {
 			return nil
 		}
 
-		return findFileRelativeToDir(dir.Parent, paths)
-	}
+		switch curPath = strings.ToLower(curPath); curPath {
+		case ".":
+			continue
+		case "..":
+			parent := ParentDir(currentDir, rootDir)
+			if parent == nil {
+				// Can't go further up. Return nothing
+				return nil
+			}
+			current = parent
+			continue
+		}
 
-	file, ok := dir.Files[curPath]
-	if !ok {
-		return nil
-	}
+		file, ok := currentDir.Files[curPath]
+		if !ok {
+			return nil
+		}
 
-	dir, ok = file.(*Directory)
-	if !ok {
-		return nil
+		if link, ok := file.(*Symlink); ok && (followLast || i < len(paths)-1) {
+			// Link targets are relative to the directory holding the link
+			file = findFile(currentDir, rootDir, link.Target, true, depth+1)
+			if file == nil {
+				return nil
+			}
+		}
+
+		if d, ok := file.(*Directory); ok {
+			file = enter(d, rootDir)
+		}
+		current = file
 	}
 
-	return findFileRelativeToDir(dir, paths)
+	return current
 }
 
 // Returns nil if path invalid or file not found
 func FindFileRelative(currentDir *Directory, rootDir *Directory, path string) File {
-	var startingDir *Directory
-	if path == "/" {
-		return rootDir
-	}
-
-	if strings.HasPrefix(path, "/") {
-		startingDir = rootDir
-	} else {
-		startingDir = currentDir
-	}
-
-	path = strings.Trim(path, "/")
-	paths := strings.Split(path, "/")
+	return findFile(currentDir, rootDir, path, true, 0)
+}
 
-	return findFileRelativeToDir(startingDir, paths)
+// FindFileRelativeNoFollow is like FindFileRelative but returns a symbolic
+// link itself, rather than its target, when it is the last part of the path.
+func FindFileRelativeNoFollow(currentDir *Directory, rootDir *Directory, path string) File {
+	return findFile(currentDir, rootDir, path, false, 0)
 }
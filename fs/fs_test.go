@@ -0,0 +1,190 @@
+package fs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var testTime = time.Date(2031, time.March, 14, 9, 0, 0, 0, time.UTC)
+
+// testTree builds /home/user/notes.txt and /etc/motd
+func testTree(t *testing.T) (root, home *Directory) {
+	root = NewDirectory(nil, "", testTime)
+	home, err := MkdirAll(root, "/home/user", testTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etc, err := MkdirAll(root, "/etc", testTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	home.Files["notes.txt"] = NewText("notes.txt", []byte("notes"), testTime)
+	etc.Files["motd"] = NewText("motd", []byte("welcome"), testTime)
+	return root, home
+}
+
+func addLink(dir *Directory, name, target string) *Symlink {
+	link := NewSymlink(name, target, testTime)
+	dir.Files[name] = link
+	return link
+}
+
+func textContents(t *testing.T, file File) string {
+	t.Helper()
+	text, ok := file.(*Text)
+	if !ok {
+		t.Fatalf("got %T, want a text file", file)
+	}
+	return string(text.Contents)
+}
+
+func TestSymlinkTargets(t *testing.T) {
+	root, home := testTree(t)
+	addLink(home, "relative", "notes.txt")
+	addLink(home, "up", "../../etc/motd")
+	addLink(home, "absolute", "/etc/motd")
+	addLink(home, "dir", "/etc")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"relative", "notes"},
+		{"up", "welcome"},
+		{"absolute", "welcome"},
+		{"dir/motd", "welcome"},
+		{"/home/user/relative", "notes"},
+	}
+	for _, tt := range tests {
+		file := FindFileRelative(home, root, tt.path)
+		if file == nil {
+			t.Errorf("%s: not found", tt.path)
+			continue
+		}
+		if got := textContents(t, file); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSymlinkRelativeToLinkDirectory(t *testing.T) {
+	root, home := testTree(t)
+	// The target is resolved from /home/user, not from where the lookup
+	// started
+	addLink(home, "relative", "notes.txt")
+
+	if file := FindFileRelative(root, root, "home/user/relative"); file == nil || textContents(t, file) != "notes" {
+		t.Fatalf("got %v, want /home/user/notes.txt", file)
+	}
+}
+
+func TestNoFollow(t *testing.T) {
+	root, home := testTree(t)
+	link := addLink(home, "absolute", "/etc/motd")
+
+	if got := FindFileRelativeNoFollow(home, root, "absolute"); got != link {
+		t.Fatalf("got %v, want the link itself", got)
+	}
+
+	// Links before the last component are still followed
+	addLink(home, "dir", "/etc")
+	if file := FindFileRelativeNoFollow(home, root, "dir/motd"); file == nil || textContents(t, file) != "welcome" {
+		t.Fatalf("got %v, want /etc/motd", file)
+	}
+}
+
+func TestDanglingSymlink(t *testing.T) {
+	root, home := testTree(t)
+	link := addLink(home, "dangling", "/nowhere")
+
+	if got := FindFileRelative(home, root, "dangling"); got != nil {
+		t.Fatalf("got %v, want nil", got)
+	}
+	if got := FindFileRelativeNoFollow(home, root, "dangling"); got != link {
+		t.Fatalf("got %v, want the link itself", got)
+	}
+}
+
+func TestSymlinkLoop(t *testing.T) {
+	root, home := testTree(t)
+	addLink(home, "a", "b")
+	addLink(home, "b", "a")
+	addLink(home, "self", "self")
+
+	for _, path := range []string{"a", "b", "self", "a/x"} {
+		if got := FindFileRelative(home, root, path); got != nil {
+			t.Errorf("%s: got %v, want nil", path, got)
+		}
+	}
+}
+
+func TestMaxSymlinkDepth(t *testing.T) {
+	root, home := testTree(t)
+
+	// link0 -> link1 -> ... -> linkN -> notes.txt
+	chain := func(n int) string {
+		for i := 0; i < n; i++ {
+			target := fmt.Sprintf("chain%d-%d", n, i+1)
+			if i == n-1 {
+				target = "notes.txt"
+			}
+			addLink(home, fmt.Sprintf("chain%d-%d", n, i), target)
+		}
+		return fmt.Sprintf("chain%d-0", n)
+	}
+
+	if file := FindFileRelative(home, root, chain(MaxSymlinkDepth)); file == nil || textContents(t, file) != "notes" {
+		t.Fatalf("chain of %d links: got %v, want notes.txt", MaxSymlinkDepth, file)
+	}
+	if file := FindFileRelative(home, root, chain(MaxSymlinkDepth+1)); file != nil {
+		t.Fatalf("chain of %d links: got %v, want nil", MaxSymlinkDepth+1, file)
+	}
+}
+
+func TestHardLinksShareInode(t *testing.T) {
+	_, home := testTree(t)
+	original := home.Files["notes.txt"].(*Text)
+	link := original.HardLink("copy.txt")
+	home.Files["copy.txt"] = link
+
+	if link.Inode != original.Inode {
+		t.Fatal("hard link has its own inode")
+	}
+
+	link.Contents = append(link.Contents, " and more"...)
+	link.Modified = testTime.Add(time.Hour)
+	if got := string(original.Contents); got != "notes and more" {
+		t.Fatalf("original contents %q, want the link's change", got)
+	}
+	if !original.ModTime().Equal(link.ModTime()) {
+		t.Fatal("modification times differ")
+	}
+
+	// Removing one name leaves the other intact
+	delete(home.Files, "notes.txt")
+	if got := string(home.Files["copy.txt"].(*Text).Contents); got != "notes and more" {
+		t.Fatalf("link contents %q after removing the original", got)
+	}
+	if link.Name() != "copy.txt" || original.Name() != "notes.txt" {
+		t.Fatal("links should keep their own names")
+	}
+}
+
+func TestDiskUsageCountsHardLinksOnce(t *testing.T) {
+	root, home := testTree(t)
+	size := root.Size()
+	if got := root.DiskUsage(); got != size {
+		t.Fatalf("disk usage %d, want %d before linking", got, size)
+	}
+
+	notes := home.Files["notes.txt"].(*Text)
+	home.Files["copy.txt"] = notes.HardLink("copy.txt")
+	if got := root.DiskUsage(); got != size {
+		t.Fatalf("disk usage %d after a hard link, want %d", got, size)
+	}
+	if got := root.Size(); got != size+notes.Size() {
+		t.Fatalf("size %d, want each name counted", got)
+	}
+}
@@ -0,0 +1,120 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mount attaches a directory tree from elsewhere, such as a removable drive
+// or a share on another host, over a directory in a filesystem. Paths
+// through the mount point lead into the mounted tree instead.
+type Mount struct {
+	Device string
+	Point  *Directory
+	Root   *Directory
+	// ReadOnly mounts can't be written to through the mount point
+	ReadOnly bool
+}
+
+// Mount attaches root over point, a directory beneath d, which must be the
+// root of a filesystem.
+func (d *Directory) Mount(device string, point, root *Directory) (*Mount, error) {
+	if point == d {
+		return nil, fmt.Errorf("cannot mount over /")
+	}
+	if d.MountAt(point) != nil {
+		return nil, fmt.Errorf("%s is already a mount point", PathOf(point, d))
+	}
+	if m := d.MountOfRoot(root); m != nil {
+		return nil, fmt.Errorf("%s is already mounted on %s", device, PathOf(m.Point, d))
+	}
+
+	m := &Mount{Device: device, Point: point, Root: root}
+	d.Mounts = append(d.Mounts, m)
+	return m, nil
+}
+
+// Unmount detaches a mount from the filesystem rooted at d
+func (d *Directory) Unmount(m *Mount) {
+	for i, other := range d.Mounts {
+		if other == m {
+			d.Mounts = append(d.Mounts[:i], d.Mounts[i+1:]...)
+			return
+		}
+	}
+}
+
+// MountAt returns the mount over point in the filesystem rooted at d, or nil
+func (d *Directory) MountAt(point *Directory) *Mount {
+	for _, m := range d.Mounts {
+		if m.Point == point {
+			return m
+		}
+	}
+	return nil
+}
+
+// MountOf returns the mount in the filesystem rooted at d whose tree holds
+// dir, or nil if dir isn't in a mounted tree.
+func (d *Directory) MountOf(dir *Directory) *Mount {
+	for current := dir; current != nil; current = current.Parent {
+		for _, m := range d.Mounts {
+			if m.Root == current {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// enter returns the directory reached by going into dir, which is the
+// mounted tree's root if something is mounted over it.
+func enter(dir, rootDir *Directory) *Directory {
+	// Guard against trees mounted inside themselves
+	for i := 0; i <= len(rootDir.Mounts); i++ {
+		m := rootDir.MountAt(dir)
+		if m == nil {
+			break
+		}
+		dir = m.Root
+	}
+	return dir
+}
+
+// ParentDir returns the directory above dir in the filesystem rooted at
+// rootDir. Going up from the root of a mounted tree leads to the directory
+// holding its mount point. It returns nil at the root.
+func ParentDir(dir, rootDir *Directory) *Directory {
+	if dir == rootDir {
+		return nil
+	}
+	if m := rootDir.MountOfRoot(dir); m != nil {
+		return m.Point.Parent
+	}
+	return dir.Parent
+}
+
+// PathOf returns the absolute path of dir in the filesystem rooted at
+// rootDir, through any mount points on the way.
+func PathOf(dir, rootDir *Directory) string {
+	dirNames := []string{}
+	for current := dir; current != nil && current != rootDir; current = ParentDir(current, rootDir) {
+		name := current.DirName
+		if m := rootDir.MountOfRoot(current); m != nil {
+			name = m.Point.DirName
+		}
+		dirNames = append([]string{name}, dirNames...)
+	}
+
+	return "/" + strings.Join(dirNames, "/")
+}
+
+// MountOfRoot returns the mount whose tree has dir as its root, or nil
+func (d *Directory) MountOfRoot(dir *Directory) *Mount {
+	for _, m := range d.Mounts {
+		if m.Root == dir {
+			return m
+		}
+	}
+	return nil
+}
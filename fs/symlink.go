@@ -0,0 +1,39 @@
+package fs
+
+import "time"
+
+// MaxSymlinkDepth is the number of symbolic links followed while resolving a
+// single path before giving up, which also catches link loops.
+const MaxSymlinkDepth = 16
+
+type Symlink struct {
+	LinkName string
+	Target   string
+	Created  time.Time
+	Modified time.Time
+}
+
+func NewSymlink(name, target string, now time.Time) *Symlink {
+	return &Symlink{
+		LinkName: name,
+		Target:   target,
+		Created:  now,
+		Modified: now,
+	}
+}
+
+func (s *Symlink) Type() FileType {
+	return FileTypeSymlink
+}
+
+func (s *Symlink) Name() string {
+	return s.LinkName
+}
+
+func (s *Symlink) Size() int64 {
+	return int64(len(s.Target))
+}
+
+func (s *Symlink) ModTime() time.Time {
+	return s.Modified
+}
@@ -3,11 +3,39 @@ package fs
 import (
 	"fmt"
 	"regexp"
+	"time"
 )
 
+// Inode holds the contents and timestamps of a text file. Hard links are
+// separate Text entries sharing the same Inode.
+type Inode struct {
+	Contents []byte
+	Created  time.Time
+	Modified time.Time
+}
+
 type Text struct {
 	FileName string
-	Contents []byte
+	*Inode
+}
+
+func NewText(name string, contents []byte, now time.Time) *Text {
+	return &Text{
+		FileName: name,
+		Inode: &Inode{
+			Contents: contents,
+			Created:  now,
+			Modified: now,
+		},
+	}
+}
+
+// HardLink returns a new text file entry sharing this file's contents
+func (t *Text) HardLink(name string) *Text {
+	return &Text{
+		FileName: name,
+		Inode:    t.Inode,
+	}
 }
 
 func (t *Text) Type() FileType {
@@ -18,6 +46,14 @@ func (t *Text) Name() string {
 	return t.FileName
 }
 
+func (t *Text) Size() int64 {
+	return int64(len(t.Contents))
+}
+
+func (t *Text) ModTime() time.Time {
+	return t.Modified
+}
+
 var fileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9\-\_\.]+$`)
 
 func ValidateFileName(name string) error {
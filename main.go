@@ -1,27 +1,68 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 
+	"github.com/ckiely91/shellsim/clock"
 	"github.com/ckiely91/shellsim/command"
 	"github.com/ckiely91/shellsim/screen"
+	"github.com/ckiely91/shellsim/worldgen"
 
 	termbox "github.com/nsf/termbox-go"
 )
 
 func main() {
+	defaults := worldgen.DefaultOptions()
+	seed := flag.Int64("seed", 0, "generate a random world from this seed instead of using the built-in world")
+	subnets := flag.Int("subnets", defaults.Subnets, "number of remote subnets in a generated world")
+	hosts := flag.Int("hosts", defaults.HostsPerSubnet, "number of hosts on each subnet in a generated world")
+	missionsPath := flag.String("missions", "", "load missions from this JSON file, e.g. data/missions.json for the built-in world")
+	flag.Parse()
+
+	var state *command.State
+	if *seed != 0 {
+		clk := clock.New(clock.Epoch)
+		network, localHost, err := worldgen.Generate(*seed, worldgen.Options{Subnets: *subnets, HostsPerSubnet: *hosts}, clock.Epoch)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		state = command.NewStateWithNetwork(clk, network, localHost)
+	} else {
+		state = command.NewState()
+	}
+
+	if *missionsPath != "" {
+		missions, err := loadMissions(*missionsPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		state.StartMissions(missions)
+	}
+
 	err := termbox.Init()
 	if err != nil {
 		panic(err)
 	}
 	defer termbox.Close()
 
-	state := command.NewState()
-
 	termbox.SetInputMode(termbox.InputEsc)
 
-	screen := screen.NewScreen(fmt.Sprintf("%v:%v", state.CurrentHost.Hostname, state.CurrentDir.FullPath()))
+	screen := screen.NewScreen(state.Prompt())
 	screen.Redraw()
 
 	command.EventLoop(state, screen)
 }
+
+func loadMissions(path string) ([]*command.Mission, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return command.LoadMissions(f)
+}
@@ -0,0 +1,228 @@
+package screen
+
+import (
+	"fmt"
+	"strings"
+
+	termbox "github.com/nsf/termbox-go"
+)
+
+// Pager holds the state of a full-screen view over a set of lines, used by
+// less and by automatic paging of long command output.
+type Pager struct {
+	Lines       []Line
+	Top         int
+	Search      string
+	Searching   bool
+	SearchInput []rune
+	Message     string
+}
+
+func (s *Screen) Height() int {
+	_, height := termbox.Size()
+	return height
+}
+
+// pageHeight is the number of content lines visible in the pager, leaving
+// the bottom row for the status line.
+func (s *Screen) pageHeight() int {
+	height := s.Height() - 1
+	if height < 1 {
+		return 1
+	}
+	return height
+}
+
+func (s *Screen) OpenPager(redraw bool, color termbox.Attribute, lines ...string) {
+	p := &Pager{}
+	for _, line := range lines {
+		p.Lines = append(p.Lines, Line{Line: line, Color: color})
+	}
+	s.Pager = p
+	if redraw {
+		s.Redraw()
+	}
+}
+
+func (s *Screen) ClosePager(redraw bool) {
+	s.Pager = nil
+	if redraw {
+		s.Redraw()
+	}
+}
+
+// PagerKey handles a single keypress while the pager is open.
+func (s *Screen) PagerKey(redraw bool, c rune) {
+	p := s.Pager
+	if p == nil {
+		return
+	}
+
+	if p.Searching {
+		p.SearchInput = append(p.SearchInput, c)
+		if redraw {
+			s.Redraw()
+		}
+		return
+	}
+
+	p.Message = ""
+	switch c {
+	case ' ', 'f':
+		s.scrollPager(s.pageHeight())
+	case 'b':
+		s.scrollPager(-s.pageHeight())
+	case 'j':
+		s.scrollPager(1)
+	case 'k':
+		s.scrollPager(-1)
+	case 'g':
+		p.Top = 0
+	case 'G':
+		s.scrollPager(len(p.Lines))
+	case '/':
+		p.Searching = true
+		p.SearchInput = []rune{}
+	case 'n':
+		s.findInPager(true, p.Top+1)
+	case 'N':
+		s.findInPager(false, p.Top-1)
+	case 'q':
+		s.Pager = nil
+	}
+
+	if redraw {
+		s.Redraw()
+	}
+}
+
+func (s *Screen) ScrollPager(redraw bool, n int) {
+	if s.Pager == nil {
+		return
+	}
+	s.scrollPager(n)
+	if redraw {
+		s.Redraw()
+	}
+}
+
+func (s *Screen) scrollPager(n int) {
+	p := s.Pager
+	p.Top += n
+	maxTop := len(p.Lines) - s.pageHeight()
+	if p.Top > maxTop {
+		p.Top = maxTop
+	}
+	if p.Top < 0 {
+		p.Top = 0
+	}
+}
+
+// PagerBackspace removes the last character of the search being typed.
+func (s *Screen) PagerBackspace(redraw bool) {
+	p := s.Pager
+	if p == nil || !p.Searching {
+		return
+	}
+	if len(p.SearchInput) == 0 {
+		p.Searching = false
+	} else {
+		p.SearchInput = p.SearchInput[:len(p.SearchInput)-1]
+	}
+	if redraw {
+		s.Redraw()
+	}
+}
+
+// PagerEnter submits the search being typed and jumps to the first match.
+func (s *Screen) PagerEnter(redraw bool) {
+	p := s.Pager
+	if p == nil || !p.Searching {
+		return
+	}
+	p.Searching = false
+	if len(p.SearchInput) > 0 {
+		p.Search = string(p.SearchInput)
+	}
+	p.SearchInput = nil
+	s.findInPager(true, p.Top)
+	if redraw {
+		s.Redraw()
+	}
+}
+
+// findInPager moves the top of the pager to the next line containing the
+// current search, starting at line from.
+func (s *Screen) findInPager(forward bool, from int) {
+	p := s.Pager
+	if p.Search == "" {
+		p.Message = "No previous search"
+		return
+	}
+
+	step := 1
+	if !forward {
+		step = -1
+	}
+
+	for i := from; i >= 0 && i < len(p.Lines); i += step {
+		if strings.Contains(p.Lines[i].Line, p.Search) {
+			p.Top = i
+			s.scrollPager(0)
+			return
+		}
+	}
+
+	p.Message = fmt.Sprintf("Pattern not found: %s", p.Search)
+}
+
+func (s *Screen) drawPager() {
+	p := s.Pager
+	pageHeight := s.pageHeight()
+
+	for y := 0; y < pageHeight && p.Top+y < len(p.Lines); y++ {
+		line := p.Lines[p.Top+y]
+		chars := []rune(line.Line)
+		x := 0
+		for i := 0; i < len(chars); i++ {
+			if p.Search != "" && matchAt(chars, i, p.Search) {
+				for _, c := range p.Search {
+					termbox.SetCell(x, y, c, termbox.ColorBlack, termbox.ColorYellow)
+					x++
+				}
+				i += len([]rune(p.Search)) - 1
+				continue
+			}
+			termbox.SetCell(x, y, chars[i], line.Color, termbox.ColorDefault)
+			x++
+		}
+	}
+
+	var status string
+	switch {
+	case p.Searching:
+		status = "/" + string(p.SearchInput)
+	case p.Message != "":
+		status = p.Message
+	default:
+		last := p.Top + pageHeight
+		if last >= len(p.Lines) {
+			status = fmt.Sprintf("lines %d-%d/%d (END) - q to quit", p.Top+1, len(p.Lines), len(p.Lines))
+		} else {
+			status = fmt.Sprintf("lines %d-%d/%d - space, b, g, G, /, n, N, q", p.Top+1, last, len(p.Lines))
+		}
+	}
+
+	x := 0
+	for _, c := range status {
+		termbox.SetCell(x, pageHeight, c, termbox.ColorBlack, termbox.ColorWhite)
+		x++
+	}
+	if p.Searching {
+		termbox.SetCell(x, pageHeight, ' ', termbox.ColorBlack, termbox.ColorWhite)
+	}
+}
+
+func matchAt(chars []rune, i int, search string) bool {
+	return strings.HasPrefix(string(chars[i:]), search)
+}
@@ -15,6 +15,10 @@ type Screen struct {
 	CurPath    string
 	CursorPosX int
 	EditLine   []rune
+	Pager      *Pager
+	SidePane   []string
+	// StatusLine is shown in a bar across the top of the screen when set
+	StatusLine string
 }
 
 func NewScreen(curPath string) *Screen {
@@ -30,7 +34,18 @@ func NewScreen(curPath string) *Screen {
 
 func (s *Screen) Redraw() {
 	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
-	_, height := termbox.Size()
+	if s.Pager != nil {
+		s.drawPager()
+		termbox.Flush()
+		return
+	}
+
+	width, height := termbox.Size()
+
+	top := s.drawStatusLine(width)
+
+	// Output lines stop short of the side pane, if one is showing
+	maxX := width - s.drawSidePane(width, height, top)
 
 	y := height - 1
 
@@ -61,12 +76,12 @@ func (s *Screen) Redraw() {
 	}
 
 	for i := len(s.Lines) - 1; i >= 0; i-- {
-		if y < 0 {
+		if y < top {
 			break
 		}
 
 		chars := []rune(s.Lines[i].Line)
-		for x := 0; x < len(chars); x++ {
+		for x := 0; x < len(chars) && x < maxX; x++ {
 			termbox.SetCell(x, y, chars[x], s.Lines[i].Color, termbox.ColorDefault)
 		}
 
@@ -76,6 +91,63 @@ func (s *Screen) Redraw() {
 	termbox.Flush()
 }
 
+// drawStatusLine draws the status bar along the top row, returning the
+// number of rows it takes up.
+func (s *Screen) drawStatusLine(width int) int {
+	if s.StatusLine == "" {
+		return 0
+	}
+
+	chars := []rune(s.StatusLine)
+	for x := 0; x < width; x++ {
+		c := ' '
+		if x < len(chars) {
+			c = chars[x]
+		}
+		termbox.SetCell(x, 0, c, termbox.ColorBlack, termbox.ColorRed)
+	}
+	return 1
+}
+
+// drawSidePane draws the side pane down the right of the screen between the
+// status bar and the edit line, returning the width it takes up.
+func (s *Screen) drawSidePane(width, height, top int) int {
+	if len(s.SidePane) == 0 {
+		return 0
+	}
+
+	paneWidth := 0
+	for _, line := range s.SidePane {
+		if l := len([]rune(line)); l > paneWidth {
+			paneWidth = l
+		}
+	}
+	// Leave room for the border and a space either side of it
+	paneWidth += 3
+	if paneWidth > width/2 {
+		paneWidth = width / 2
+	}
+
+	borderX := width - paneWidth
+	for y := top; y < height-1; y++ {
+		termbox.SetCell(borderX, y, '│', termbox.ColorBlue, termbox.ColorDefault)
+		if y-top >= len(s.SidePane) {
+			continue
+		}
+
+		x := borderX + 2
+		for _, c := range s.SidePane[y-top] {
+			if x >= width {
+				break
+			}
+			termbox.SetCell(x, y, c, termbox.ColorCyan, termbox.ColorDefault)
+			x++
+		}
+	}
+
+	return paneWidth
+}
+
 func (s *Screen) MoveCursorLeft(redraw bool) {
 	if !s.Editing || s.CursorPosX == 0 {
 		return
@@ -0,0 +1,112 @@
+package worldgen
+
+var roles = []string{"web", "web", "ftp", "db", "workstation", "workstation"}
+
+// idsLevels is how closely hosts in each role watch for intruders
+var idsLevels = map[string]int{"web": 1, "ftp": 1, "db": 3}
+
+// routerCommands are all that routers' restricted shells offer
+var routerCommands = []string{"show", "ping", "traceroute"}
+
+// diskSizes are the storage capacities hosts are given, in megabytes
+var diskSizes = []int64{1, 2, 4, 16, 64}
+
+// hardenedDisabled are the commands locked down servers take away
+var hardenedDisabled = []string{"replace", "ln"}
+
+var sshVersions = []string{
+	"OpenSSH 5.3",
+	"OpenSSH 6.6",
+	"OpenSSH 7.4",
+	"OpenSSH 8.2",
+	"Dropbear 2014.63",
+}
+
+var httpVersions = []string{
+	"Apache 2.2.15",
+	"Apache 2.4.7",
+	"nginx 1.10.3",
+	"nginx 1.14.0",
+	"IIS 7.5",
+}
+
+var ftpVersions = []string{
+	"vsftpd 2.3.4",
+	"vsftpd 3.0.3",
+	"ProFTPD 1.3.5",
+	"Pure-FTPd 1.0.47",
+}
+
+var dbVersions = []string{
+	"MySQL 5.5.62",
+	"MySQL 5.7.24",
+	"PostgreSQL 9.6",
+	"MariaDB 10.1.37",
+}
+
+var weakPasswords = []string{
+	"123456",
+	"password",
+	"qwerty",
+	"letmein",
+	"dragon",
+	"monkey",
+	"sunshine",
+	"princess",
+	"football",
+	"admin",
+	"welcome",
+	"trustno1",
+	"iloveyou",
+	"master",
+	"shadow",
+}
+
+var firstNames = []string{
+	"alice",
+	"bob",
+	"carol",
+	"dave",
+	"erin",
+	"frank",
+	"grace",
+	"heidi",
+	"ivan",
+	"judy",
+	"mallory",
+	"oscar",
+	"peggy",
+	"trent",
+	"victor",
+	"walter",
+}
+
+var companyPrefixes = []string{
+	"acme",
+	"globex",
+	"initech",
+	"umbrella",
+	"hooli",
+	"cyberdyne",
+	"tyrell",
+	"wayne",
+	"stark",
+	"oscorp",
+}
+
+var companySuffixes = []string{
+	"",
+	"corp",
+	"labs",
+	"systems",
+	"net",
+}
+
+var tlds = []string{"com", "net", "org", "io"}
+
+var noteTemplates = []string{
+	"Remember to rotate the backup tapes before Friday. %s audit is next month.\n",
+	"TODO: ask IT why the VPN keeps dropping. %s helpdesk never answers.\n",
+	"Meeting notes: %s wants the migration finished by end of quarter.\n",
+	"Don't forget - the new starter at %s still needs an account.\n",
+}
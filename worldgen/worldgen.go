@@ -0,0 +1,377 @@
+// Package worldgen builds random worlds for the simulator. The same seed and
+// options always produce the same world, so a seed is enough to reproduce
+// a bug report.
+package worldgen
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ckiely91/shellsim/command"
+	"github.com/ckiely91/shellsim/fs"
+)
+
+type Options struct {
+	// Subnets is the number of remote subnets hanging off the core router
+	Subnets int
+	// HostsPerSubnet is the number of hosts on each remote subnet
+	HostsPerSubnet int
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Subnets:        4,
+		HostsPerSubnet: 3,
+	}
+}
+
+// maxHostsPerSubnet is how many hosts fit in a /24 beside its gateway
+const maxHostsPerSubnet = 252
+
+// Validate checks the options describe a world that can be built
+func (o Options) Validate() error {
+	if o.Subnets < 0 {
+		return fmt.Errorf("subnets must not be negative")
+	}
+	if o.HostsPerSubnet < 0 || o.HostsPerSubnet > maxHostsPerSubnet {
+		return fmt.Errorf("hosts per subnet must be between 0 and %d", maxHostsPerSubnet)
+	}
+	return nil
+}
+
+type generator struct {
+	rnd         *rand.Rand
+	now         time.Time
+	network     *command.Network
+	dns         *command.Host
+	dnsIP       net.IP
+	usedSubnets map[string]bool
+	usedDomains map[string]bool
+}
+
+// Generate builds a network from a seed, returning it along with the
+// player's local host. now is the time the world is created at; files are
+// given timestamps before it.
+func Generate(seed int64, opts Options, now time.Time) (*command.Network, *command.Host, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	g := &generator{
+		rnd:         rand.New(rand.NewSource(seed)),
+		now:         now,
+		network:     command.NewNetwork(),
+		usedSubnets: map[string]bool{},
+		usedDomains: map[string]bool{},
+	}
+
+	// The core router links the player's home network to every remote
+	// subnet, and doubles as the world's DNS server.
+	backbone := g.network.AddSubnet(cidr(100, 64, 0), 5*time.Millisecond)
+	g.usedSubnets[backbone.CIDR.String()] = true
+
+	core := command.NewHost("100.64.0.1", g.past())
+	core.Forwarding = true
+	core.RestrictCommands(routerCommands...)
+	core.AddInterface("eth0", net.IPv4(100, 64, 0, 1), backbone)
+	core.AddService(53, command.ServiceTypeDNS, "BIND 9.9.4", "")
+	g.dns, g.dnsIP = core, core.PrimaryIP()
+	g.addHost(core)
+
+	localHost := g.homeNetwork(backbone, core)
+
+	for i := 0; i < opts.Subnets; i++ {
+		g.remoteSubnet(core, i+1, opts.HostsPerSubnet)
+	}
+
+	return g.network, localHost, nil
+}
+
+// homeNetwork creates the player's LAN with their machine behind a home
+// router on the backbone.
+func (g *generator) homeNetwork(backbone *command.Subnet, core *command.Host) *command.Host {
+	lanCIDR := cidr(192, 168, byte(g.rnd.Intn(255)))
+	g.usedSubnets[lanCIDR.String()] = true
+	lan := g.network.AddSubnet(lanCIDR, 1*time.Millisecond)
+
+	routerWAN := net.IPv4(100, 64, 0, 2)
+	routerLAN := hostIP(lanCIDR, 1)
+	router := command.NewHost(routerLAN.String(), g.past())
+	router.Forwarding = true
+	router.RestrictCommands(routerCommands...)
+	router.AddInterface("eth0", routerLAN, lan)
+	router.AddInterface("eth1", routerWAN, backbone)
+	router.AddRoute(defaultRoute(), core.PrimaryIP())
+	g.addHost(router)
+
+	core.AddRoute(lanCIDR, routerWAN)
+
+	localIP := hostIP(lanCIDR, byte(2+g.rnd.Intn(98)))
+	localHost := command.NewHost(localIP.String(), g.past())
+	localHost.AddInterface("eth0", localIP, lan)
+	localHost.AddRoute(defaultRoute(), routerLAN)
+	localHost.AddService(22, command.ServiceTypeSSH, pick(g.rnd, sshVersions), "")
+	localHost.WriteFile("/etc/hosts", fmt.Sprintf("%s localhost\n%s router gateway\n", localIP, routerLAN), g.past())
+	localHost.WriteFile("/root/readme.txt", "Use scan to find other hosts and map to see what you've found.\n", g.past())
+	localHost.WriteFile(command.DefaultWordlistPath, strings.Join(weakPasswords, "\n")+"\n", g.past())
+	for _, program := range []string{"portscan", "crack"} {
+		localHost.InstallProgram("/usr/bin", program, g.past())
+	}
+	g.addHost(localHost)
+
+	return localHost
+}
+
+// remoteSubnet creates a subnet on the core router full of hosts. Some
+// subnets also hide an internal network behind one of their hosts, which can
+// only be reached by connecting to that host first.
+func (g *generator) remoteSubnet(core *command.Host, index, hosts int) {
+	subnetCIDR := g.publicSubnet()
+	subnet := g.network.AddSubnet(subnetCIDR, time.Duration(5+g.rnd.Intn(40))*time.Millisecond)
+
+	gateway := hostIP(subnetCIDR, 1)
+	core.AddInterface(fmt.Sprintf("eth%d", index), gateway, subnet)
+
+	company := g.company()
+	tld := pick(g.rnd, tlds)
+	members := []*command.Host{}
+	for _, octet := range g.octets(hosts) {
+		ip := hostIP(subnetCIDR, octet)
+		host := g.server(ip, subnet, company, tld)
+		host.AddRoute(defaultRoute(), gateway)
+		if host.DomainName != "" {
+			g.dns.AddDNSRecord(host.DomainName, ip)
+		}
+		members = append(members, host)
+	}
+
+	if len(members) == 0 || g.rnd.Intn(2) == 0 {
+		return
+	}
+
+	// Pick a host to be dual homed onto a private network
+	pivot := members[g.rnd.Intn(len(members))]
+	internalCIDR := g.privateSubnet()
+	internal := g.network.AddSubnet(internalCIDR, 1*time.Millisecond)
+	pivotIP := hostIP(internalCIDR, 1)
+	pivot.AddInterface("eth1", pivotIP, internal)
+
+	// Internal hosts aren't in public DNS, but the pivot knows their names
+	hostsFile := ""
+	for _, octet := range g.octets(1 + g.rnd.Intn(3)) {
+		ip := hostIP(internalCIDR, octet)
+		host := g.server(ip, internal, company, "internal")
+		host.AddRoute(defaultRoute(), pivotIP)
+		if host.DomainName != "" {
+			hostsFile += fmt.Sprintf("%s %s\n", ip, host.DomainName)
+		}
+	}
+	if hostsFile != "" {
+		pivot.WriteFile("/etc/hosts", hostsFile, g.past())
+	}
+}
+
+// server creates a host with a random role, along with the services, users
+// and files that go with it.
+func (g *generator) server(ip net.IP, subnet *command.Subnet, company, tld string) *command.Host {
+	host := command.NewHost(ip.String(), g.past())
+	host.AddInterface("eth0", ip, subnet)
+	host.AddService(22, command.ServiceTypeSSH, pick(g.rnd, sshVersions), "")
+
+	role := pick(g.rnd, roles)
+	switch role {
+	case "web":
+		version := pick(g.rnd, httpVersions)
+		host.AddService(80, command.ServiceTypeHTTP, version, strings.Replace(version, " ", "/", 1))
+		host.WriteFile("/var/www/index.html", fmt.Sprintf("<html><h1>Welcome to %s</h1></html>\n", title(company)), g.past())
+		host.WriteFile("/etc/crontab", "# m h dom mon dow user command\n0 3 * * * root rm /var/log/audit.log\n", g.past())
+	case "ftp":
+		version := pick(g.rnd, ftpVersions)
+		host.AddService(21, command.ServiceTypeFTP, version, fmt.Sprintf("220 (%s)", version))
+		host.WriteFile("/srv/ftp/readme.txt", fmt.Sprintf("%s file drop. Uploads are purged weekly.\n", title(company)), g.past())
+		if g.rnd.Intn(2) == 0 {
+			host.AddService(2049, command.ServiceTypeNFS, "nfs-kernel-server 1.2.8", "")
+			host.WriteFile("/etc/exports", "/srv/ftp *(rw,no_root_squash)\n", g.past())
+		}
+	case "db":
+		port, version := 3306, pick(g.rnd, dbVersions)
+		if strings.HasPrefix(version, "PostgreSQL") {
+			port = 5432
+		}
+		host.AddService(port, command.ServiceTypeDB, version, "")
+		customers, key, when := g.customers(), g.password(command.PasswordMedium), g.past()
+		host.WriteFile("/var/lib/db/customers.csv", customers, when)
+
+		// Nightly backups are encrypted, with the key left lying around
+		backup := fs.NewDirectory(nil, "", when)
+		fs.WriteFile(backup, "/customers.csv", fs.NewEncrypted("customers.csv", []byte(customers), key, when), when)
+		fs.WriteFile(host.RootDir, "/var/backups/nightly.zip", fs.NewArchive("nightly.zip", backup, when), when)
+		host.WriteFile("/etc/backup.conf", fmt.Sprintf("target=/var/backups/nightly.zip\nkey=%s\n", key), when)
+	}
+
+	if role != "workstation" {
+		host.DomainName = g.domain(role, company, tld)
+	}
+	host.SetIDSLevel(idsLevels[role])
+	host.Capacity = diskSizes[g.rnd.Intn(len(diskSizes))] * 1024 * 1024
+
+	// Servers with something worth protecting often only expose their services
+	if role != "workstation" && g.rnd.Intn(3) == 0 {
+		host.Firewall.DefaultPolicy = command.FirewallDeny
+		for _, service := range host.SortedServices() {
+			host.Firewall.AddRule(command.FirewallAllow, "*", service.Port)
+		}
+		host.DisableCommands(hardenedDisabled...)
+	}
+
+	g.users(host, company)
+	g.addHost(host)
+
+	return host
+}
+
+// users adds a root password and a few regular accounts to a host, each with
+// some files in their home directory.
+func (g *generator) users(host *command.Host, company string) {
+	host.PasswordStrength = command.PasswordStrength(g.rnd.Intn(3))
+	host.Users["root"].Password = g.password(host.PasswordStrength)
+
+	names := []string{}
+	for _, name := range shuffled(g.rnd, firstNames)[:g.rnd.Intn(4)] {
+		user, err := host.AddUser(name, "/home/"+name, g.past())
+		if err != nil {
+			continue
+		}
+		user.Password = g.password(host.PasswordStrength)
+		names = append(names, name)
+
+		host.WriteFile(fmt.Sprintf("/home/%s/notes.txt", name), fmt.Sprintf(pick(g.rnd, noteTemplates), title(company)), g.past())
+	}
+
+	passwd := "root:x:0:0:root:/root:/bin/sh\n"
+	for i, name := range names {
+		passwd += fmt.Sprintf("%s:x:%d:%d::/home/%s:/bin/sh\n", name, 1000+i, 1000+i, name)
+	}
+	host.WriteFile("/etc/passwd", passwd, g.past())
+}
+
+func (g *generator) addHost(host *command.Host) {
+	host.WriteFile("/etc/resolv.conf", fmt.Sprintf("nameserver %s\n", g.dnsIP), g.past())
+	host.WriteFile("/var/log/syslog", fmt.Sprintf("%s %s systemd[1]: Started OpenBSD Secure Shell server.\n", g.past().Format(time.Stamp), host.Hostname), g.past())
+	g.network.AddHost(host)
+}
+
+// past returns a random time in the year before the world was created
+func (g *generator) past() time.Time {
+	return g.now.Add(-time.Duration(g.rnd.Int63n(int64(365 * 24 * time.Hour))))
+}
+
+// password picks a password that meets the host's policy. People pick
+// something as guessable as they're allowed to.
+func (g *generator) password(strength command.PasswordStrength) string {
+	switch strength {
+	case command.PasswordWeak:
+		return pick(g.rnd, weakPasswords)
+	case command.PasswordMedium:
+		return fmt.Sprintf("%s%d", pick(g.rnd, weakPasswords), g.rnd.Intn(100))
+	}
+
+	const chars = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789!@#$%"
+	password := make([]byte, 10+g.rnd.Intn(6))
+	for i := range password {
+		password[i] = chars[g.rnd.Intn(len(chars))]
+	}
+	return string(password)
+}
+
+func (g *generator) company() string {
+	return pick(g.rnd, companyPrefixes) + pick(g.rnd, companySuffixes)
+}
+
+func (g *generator) domain(role, company, tld string) string {
+	prefix := map[string]string{"web": "www", "ftp": "ftp", "db": "db"}[role]
+	domain := fmt.Sprintf("%s.%s.%s", prefix, company, tld)
+	for i := 2; g.usedDomains[domain]; i++ {
+		domain = fmt.Sprintf("%s%d.%s.%s", prefix, i, company, tld)
+	}
+	g.usedDomains[domain] = true
+	return domain
+}
+
+func (g *generator) customers() string {
+	lines := []string{"id,name,email,card"}
+	for i := 1; i <= 5+g.rnd.Intn(10); i++ {
+		name := pick(g.rnd, firstNames)
+		lines = append(lines, fmt.Sprintf("%d,%s,%s@mail.com,%04d-XXXX-XXXX-%04d", i, title(name), name, 4000+g.rnd.Intn(1000), g.rnd.Intn(10000)))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// publicSubnet picks an unused /24 outside the private and reserved ranges
+func (g *generator) publicSubnet() *net.IPNet {
+	for {
+		a := byte(11 + g.rnd.Intn(212))
+		if a == 100 || a == 127 || a == 169 || a == 172 || a == 192 {
+			continue
+		}
+		subnet := cidr(a, byte(g.rnd.Intn(256)), byte(g.rnd.Intn(256)))
+		if !g.usedSubnets[subnet.String()] {
+			g.usedSubnets[subnet.String()] = true
+			return subnet
+		}
+	}
+}
+
+func (g *generator) privateSubnet() *net.IPNet {
+	for {
+		subnet := cidr(10, byte(g.rnd.Intn(256)), byte(g.rnd.Intn(256)))
+		if !g.usedSubnets[subnet.String()] {
+			g.usedSubnets[subnet.String()] = true
+			return subnet
+		}
+	}
+}
+
+// octets picks n distinct host addresses in a /24, avoiding the gateway
+func (g *generator) octets(n int) []byte {
+	octets := []byte{}
+	for _, i := range g.rnd.Perm(maxHostsPerSubnet)[:n] {
+		octets = append(octets, byte(i+2))
+	}
+	return octets
+}
+
+func defaultRoute() *net.IPNet {
+	return &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+}
+
+func cidr(a, b, c byte) *net.IPNet {
+	return &net.IPNet{IP: net.IPv4(a, b, c, 0).To4(), Mask: net.CIDRMask(24, 32)}
+}
+
+func hostIP(subnet *net.IPNet, octet byte) net.IP {
+	ip := subnet.IP.To4()
+	return net.IPv4(ip[0], ip[1], ip[2], octet)
+}
+
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func pick(rnd *rand.Rand, options []string) string {
+	return options[rnd.Intn(len(options))]
+}
+
+func shuffled(rnd *rand.Rand, options []string) []string {
+	out := make([]string, len(options))
+	for i, j := range rnd.Perm(len(options)) {
+		out[i] = options[j]
+	}
+	return out
+}
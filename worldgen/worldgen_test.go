@@ -0,0 +1,133 @@
+package worldgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ckiely91/shellsim/command"
+	"github.com/ckiely91/shellsim/fs"
+)
+
+var testTime = time.Date(2031, time.March, 14, 9, 0, 0, 0, time.UTC)
+
+// describe writes out everything about a world that should depend only on
+// the seed: hosts, their addresses and services, users and passwords, and
+// every file.
+func describe(network *command.Network, localHost *command.Host) string {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "local %s\n", localHost.Hostname)
+	for _, host := range network.Hosts {
+		fmt.Fprintf(buf, "host %s %s\n", host.Hostname, host.DomainName)
+		for _, iface := range host.Interfaces {
+			fmt.Fprintf(buf, "  iface %s\n", iface)
+		}
+		for _, service := range host.SortedServices() {
+			fmt.Fprintf(buf, "  service %d %s %s\n", service.Port, service.Type, service.Version)
+		}
+
+		names := []string{}
+		for name := range host.Users {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			user := host.Users[name]
+			fmt.Fprintf(buf, "  user %s %s %q\n", user.Name, user.Home, user.Password)
+		}
+
+		describeDir(buf, host.RootDir, "/")
+	}
+	return buf.String()
+}
+
+func describeDir(buf *strings.Builder, dir *fs.Directory, path string) {
+	names := []string{}
+	for name := range dir.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		file := dir.Files[name]
+		fmt.Fprintf(buf, "  file %s%s %d %d %s\n", path, file.Name(), file.Type(), file.Size(), file.ModTime().Format(time.RFC3339))
+		switch file := file.(type) {
+		case *fs.Directory:
+			describeDir(buf, file, path+file.Name()+"/")
+		case *fs.Text:
+			fmt.Fprintf(buf, "    %q\n", file.Contents)
+		}
+	}
+}
+
+func generate(t *testing.T, seed int64, opts Options) string {
+	t.Helper()
+	network, localHost, err := Generate(seed, opts, testTime)
+	if err != nil {
+		t.Fatalf("Generate(%d): %v", seed, err)
+	}
+	return describe(network, localHost)
+}
+
+func TestSameSeedSameWorld(t *testing.T) {
+	for _, seed := range []int64{1, 42, 1234567} {
+		first, second := generate(t, seed, DefaultOptions()), generate(t, seed, DefaultOptions())
+		if first != second {
+			t.Errorf("seed %d gave two different worlds:\n%s\n---\n%s", seed, first, second)
+		}
+	}
+}
+
+func TestDifferentSeedsDifferentWorlds(t *testing.T) {
+	seen := map[string]int64{}
+	for _, seed := range []int64{1, 2, 3, 42, 1234567} {
+		world := generate(t, seed, DefaultOptions())
+		if other, ok := seen[world]; ok {
+			t.Errorf("seeds %d and %d gave the same world", other, seed)
+		}
+		seen[world] = seed
+	}
+}
+
+func TestOptionsShapeWorld(t *testing.T) {
+	hosts := func(opts Options) int {
+		network, _, err := Generate(7, opts, testTime)
+		if err != nil {
+			t.Fatalf("%+v: %v", opts, err)
+		}
+		return len(network.Hosts)
+	}
+
+	small, large := hosts(Options{Subnets: 1, HostsPerSubnet: 1}), hosts(Options{Subnets: 6, HostsPerSubnet: maxHostsPerSubnet})
+	if large <= small {
+		t.Fatalf("%d hosts with the largest options, no more than %d with the smallest", large, small)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		opts  Options
+		valid bool
+	}{
+		{DefaultOptions(), true},
+		{Options{Subnets: 0, HostsPerSubnet: 0}, true},
+		{Options{Subnets: 1, HostsPerSubnet: maxHostsPerSubnet}, true},
+		{Options{Subnets: -1, HostsPerSubnet: 3}, false},
+		{Options{Subnets: 4, HostsPerSubnet: -1}, false},
+		{Options{Subnets: 4, HostsPerSubnet: maxHostsPerSubnet + 1}, false},
+		{Options{Subnets: 4, HostsPerSubnet: 300}, false},
+	}
+	for _, tt := range tests {
+		err := tt.opts.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("%+v: Validate() = %v, want valid %v", tt.opts, err, tt.valid)
+		}
+
+		// Generate must refuse the same options rather than panic
+		if _, _, err := Generate(1, tt.opts, testTime); (err == nil) != tt.valid {
+			t.Errorf("%+v: Generate() error = %v, want valid %v", tt.opts, err, tt.valid)
+		}
+	}
+}